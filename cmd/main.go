@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"music-library/config"
 	"music-library/controllers"
 	"music-library/database"
+	"music-library/enrichment"
+	"music-library/httpclient"
 	"music-library/repository"
-	"music-library/utils"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -37,11 +43,220 @@ func initLog(level string) {
 	}
 }
 
+// newStore выбирает хранилище по STORAGE_DRIVER: "memory" не требует Postgres.
+func newStore(cfg *config.Config) repository.SongStore {
+	if cfg.STORAGE_DRIVER == "memory" {
+		log.Info("Using in-memory storage.")
+		return repository.NewMemoryStore()
+	}
+
+	dbInstance := database.NewDatabase()
+	err := dbInstance.Connect()
+	if err != nil {
+		log.WithError(err).Fatal("Failde to connect to database")
+	}
+
+	log.Info("Database connected successfully.")
+
+	db := dbInstance.GetDB()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load migrations")
+	}
+	if cfg.AUTO_MIGRATE == "true" {
+		if err := migrator.Up(); err != nil {
+			log.WithError(err).Fatal("Failed to apply migrations")
+		}
+		log.Info("Database migrations completed.")
+	} else if pending, err := migrator.Pending(); err != nil {
+		log.WithError(err).Fatal("Failed to check migrations")
+	} else if len(pending) > 0 {
+		log.Warnf("%d pending migrations, run `migrate up` to apply them", len(pending))
+	}
+
+	return repository.NewSongRepository(db)
+}
+
+// newCatalog загружает каталог песен из ENRICHMENT_CATALOG и следит за изменениями его файлов.
+func newCatalog(cfg *config.Config) (*enrichment.Catalog, error) {
+	paths := cfg.ENRICHMENT_CATALOG
+	if paths == "" {
+		paths = "song_enrichment.json"
+	}
+	catalog, err := enrichment.NewCatalog(strings.Split(paths, ",")...)
+	if err != nil {
+		return nil, err
+	}
+
+	reload := 5 * time.Second
+	if cfg.ENRICHMENT_CATALOG_RELOAD != "" {
+		reload, err = time.ParseDuration(cfg.ENRICHMENT_CATALOG_RELOAD)
+		if err != nil || reload < 0 {
+			return nil, fmt.Errorf("invalid ENRICHMENT_CATALOG_RELOAD %q", cfg.ENRICHMENT_CATALOG_RELOAD)
+		}
+	}
+	if reload > 0 {
+		go catalog.Watch(context.Background(), reload)
+	}
+	return catalog, nil
+}
+
+// newAPIClient создаёт клиент внешнего API с настройками из EXTERNAL_API_*.
+func newAPIClient(cfg *config.Config) (*httpclient.Client, error) {
+	opts := httpclient.DefaultOptions
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"EXTERNAL_API_TIMEOUT", cfg.EXTERNAL_API_TIMEOUT, &opts.Timeout},
+		{"EXTERNAL_API_BREAKER_COOLDOWN", cfg.EXTERNAL_API_BREAKER_COOLDOWN, &opts.BreakerCooldown},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.dst = parsed
+	}
+
+	counts := []struct {
+		name  string
+		value string
+		dst   *int
+	}{
+		{"EXTERNAL_API_RETRIES", cfg.EXTERNAL_API_RETRIES, &opts.Retries},
+		{"EXTERNAL_API_BREAKER_THRESHOLD", cfg.EXTERNAL_API_BREAKER_THRESHOLD, &opts.BreakerThreshold},
+	}
+	for _, c := range counts {
+		if c.value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(c.value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid %s %q", c.name, c.value)
+		}
+		*c.dst = parsed
+	}
+	return httpclient.New(&http.Client{}, opts), nil
+}
+
+// newEnrichmentCache создаёт кэш ответов внешнего API из ENRICHMENT_CACHE_*; nil, если кэш отключён.
+func newEnrichmentCache(cfg *config.Config) (*enrichment.Cache, error) {
+	opts := enrichment.CacheOptions{Size: enrichment.DefaultCacheSize}
+	if cfg.ENRICHMENT_CACHE_SIZE != "" {
+		size, err := strconv.Atoi(cfg.ENRICHMENT_CACHE_SIZE)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid ENRICHMENT_CACHE_SIZE %q", cfg.ENRICHMENT_CACHE_SIZE)
+		}
+		if size == 0 {
+			return nil, nil
+		}
+		opts.Size = size
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"ENRICHMENT_CACHE_TTL", cfg.ENRICHMENT_CACHE_TTL, &opts.TTL},
+		{"ENRICHMENT_CACHE_NOT_FOUND_TTL", cfg.ENRICHMENT_CACHE_NOT_FOUND_TTL, &opts.NotFoundTTL},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid %s %q", d.name, d.value)
+		}
+		*d.dst = parsed
+	}
+	// Общее хранилище кэша не настроено: каждый экземпляр сервиса кэширует сам
+	return enrichment.NewCache(opts, nil), nil
+}
+
+// newEnrichmentChain собирает цепочку источников GET /info из ENRICHMENT_PROVIDERS.
+func newEnrichmentChain(cfg *config.Config, store repository.SongStore, catalog *enrichment.Catalog, client *httpclient.Client, cache *enrichment.Cache) (*enrichment.Chain, error) {
+	timeout := enrichment.DefaultTimeout
+	if cfg.ENRICHMENT_TIMEOUT != "" {
+		parsed, err := time.ParseDuration(cfg.ENRICHMENT_TIMEOUT)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid ENRICHMENT_TIMEOUT %q", cfg.ENRICHMENT_TIMEOUT)
+		}
+		timeout = parsed
+	}
+
+	providers := cfg.ENRICHMENT_PROVIDERS
+	if providers == "" {
+		providers = "database,catalog"
+		if cfg.EXTERNAL_API_URL != "" {
+			providers = "database,api,catalog"
+		}
+	}
+
+	var steps []enrichment.Step
+	for _, entry := range strings.Split(providers, ",") {
+		name, providerTimeout, hasTimeout := strings.Cut(strings.TrimSpace(entry), ":")
+		step := enrichment.Step{Timeout: timeout}
+		if hasTimeout {
+			parsed, err := time.ParseDuration(providerTimeout)
+			if err != nil || parsed <= 0 {
+				return nil, fmt.Errorf("invalid timeout %q for enrichment provider %s", providerTimeout, name)
+			}
+			step.Timeout = parsed
+		}
+
+		switch name {
+		case "database":
+			step.Provider = enrichment.NewStoreProvider(store)
+		case "api":
+			if cfg.EXTERNAL_API_URL == "" {
+				return nil, fmt.Errorf("enrichment provider api requires EXTERNAL_API_URL")
+			}
+			step.Provider = enrichment.NewHTTPProvider(cfg.EXTERNAL_API_URL, client)
+			if cache != nil {
+				step.Provider = cache.Wrap(step.Provider)
+			}
+		case "catalog":
+			step.Provider = enrichment.NewCatalogProvider(catalog)
+		default:
+			return nil, fmt.Errorf("unknown enrichment provider %q", name)
+		}
+		steps = append(steps, step)
+	}
+	precedence := enrichment.DefaultPrecedence
+	if cfg.ENRICHMENT_PRECEDENCE != "" {
+		precedence = nil
+		for _, source := range strings.Split(cfg.ENRICHMENT_PRECEDENCE, ",") {
+			precedence = append(precedence, strings.TrimSpace(source))
+		}
+	}
+	refresh := enrichment.DefaultRefresh
+	if cfg.ENRICHMENT_REFRESH != "" {
+		parsed, err := time.ParseDuration(cfg.ENRICHMENT_REFRESH)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("invalid ENRICHMENT_REFRESH %q", cfg.ENRICHMENT_REFRESH)
+		}
+		refresh = parsed
+	}
+	return enrichment.NewChain(enrichment.NewPolicy(refresh, precedence...), steps...), nil
+}
+
 // @title Music Library API
 // @version 1.0
 // @description API для управления библиотекой песен.
 // @host localhost:5051
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token as "Bearer <token>"; /admin endpoints are disabled until ADMIN_TOKEN is set.
 func main() {
 	cfg, err := config.LoadEnv()
 	if err != nil {
@@ -50,45 +265,78 @@ func main() {
 
 	initLog(cfg.LOG_LEVEL)
 
-	dbInstance := database.NewDatabase()
-	err = dbInstance.Connect()
-	if err != nil {
-		log.WithError(err).Fatal("Failde to connect to database")
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
 	}
 
-	log.Info("Database connected successfully.")
-
-	db := dbInstance.GetDB()
-
-	database.Migrate(db)
-	log.Info("Database migrations completed.")
-
-	songRep := repository.NewSongRepository(db)
+	store := newStore(cfg)
+	catalog, err := newCatalog(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load enrichment catalog")
+	}
+	log.Infof("Enrichment catalog loaded: %d songs", catalog.Len())
+	apiClient, err := newAPIClient(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure external API client")
+	}
+	cache, err := newEnrichmentCache(cfg)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure enrichment cache")
+	}
+	chain, err := newEnrichmentChain(cfg, store, catalog, apiClient, cache)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to configure enrichment providers")
+	}
+	log.Infof("Enrichment providers: %s, precedence: %s",
+		strings.Join(chain.Providers(), ", "), strings.Join(chain.Policy().Precedence(), " > "))
+	h := controllers.NewHandler(store, cfg, chain, cache)
 
 	r := gin.Default()
+	r.Use(controllers.RequestID(), controllers.ErrorHandler())
+	r.NoRoute(controllers.NoRoute)
 
-	r.GET("/info", controllers.GetSongInfo)
-	r.GET("/songs", func(c *gin.Context) {
-		page := c.DefaultQuery("page", "1")
-		limit := c.DefaultQuery("limit", "10")
-		songs, err := songRep.GetAllSongs(utils.ToInt(page), utils.ToInt(limit))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, songs)
-	})
-	r.POST("/songs", controllers.CreateSong)
-	r.GET("/song/:id/verses", controllers.GetSongTextWithPagination)
-	r.PUT("/song/:id", controllers.UpdateSong)
-	r.PATCH("/song/:id", controllers.PartialUpdateSong)
-	r.DELETE("/song/:id", controllers.DeleteSong)
+	r.GET("/info", h.GetSongInfo)
+	r.GET("/songs", h.GetSongs)
+	r.GET("/search", h.SearchSongs)
+	r.GET("/search/fuzzy", h.FuzzySearch)
+	r.GET("/suggest", h.Suggest)
+	r.GET("/groups", h.ListGroups)
+	r.GET("/groups/:id", h.GetGroup)
+	r.PATCH("/groups/:id", h.RenameGroup)
+	r.DELETE("/groups/:id", h.DeleteGroup)
+	r.GET("/groups/:id/songs", h.GetGroupSongs)
+	r.POST("/songs", h.CreateSong)
+	// Песни по ID — под /songs/:id; старый префикс /song/:id оставлен для существующих клиентов
+	// и в документацию не попадает.
+	for _, prefix := range []string{"/songs/:id", "/song/:id"} {
+		r.GET(prefix, h.GetSong)
+		r.GET(prefix+"/verses", h.GetSongTextWithPagination)
+		r.PUT(prefix, h.UpdateSong)
+		r.PATCH(prefix, h.PartialUpdateSong)
+		r.DELETE(prefix, h.DeleteSong)
+	}
+	r.POST("/songs/:id/restore", h.RestoreSong)
+	r.GET("/songs/:id/revisions", h.ListRevisions)
+	r.GET("/songs/:id/revisions/diff", h.DiffRevisions)
+	r.POST("/songs/:id/revisions/:rev/restore", h.RestoreRevision)
+	r.GET("/trash", h.ListTrash)
+
+	admin := r.Group("/admin", controllers.AdminAuth(cfg.ADMIN_TOKEN))
+	admin.GET("/groups/duplicates", h.ListDuplicateGroups)
+	admin.POST("/groups/merge", h.MergeGroups)
+	admin.POST("/groups/:id/merge-songs", h.MergeGroupSongs)
+	admin.POST("/trash/purge", h.PurgeTrash)
+	admin.GET("/cache", h.CacheStats)
+	admin.GET("/cache/entries", h.ListCacheEntries)
+	admin.DELETE("/cache", h.FlushCache)
+	admin.DELETE("/cache/entries", h.InvalidateCacheEntry)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	log.Info("Swagger documentation available at http://localhost:5050/swagger/index.html")
 
 	go func() {
 		testRouter := gin.Default()
+		testRouter.Use(controllers.RequestID(), controllers.ErrorHandler())
 
 		testRouter.GET("/info", func(c *gin.Context) {
 			group := c.Query("group")
@@ -96,14 +344,14 @@ func main() {
 
 			if group == "" || song == "" {
 				log.Println("DEBUG: Missing request parameters: group or song.")
-				c.JSON(http.StatusBadRequest, gin.H{"error": "missing parameters"})
+				_ = c.Error(controllers.NewAPIError(http.StatusBadRequest, controllers.CodeBadRequest, "missing parameters"))
 				return
 			}
 
-			songDetail, err := controllers.GetSongDetailFromJSON(group, song)
+			songDetail, err := catalog.Find(group, song)
 			if err != nil {
 				log.Printf("DEBUG: Error fetching song details: %v\n", err)
-				c.JSON(http.StatusNotFound, gin.H{"error": "song not found"})
+				_ = c.Error(controllers.NewAPIError(http.StatusNotFound, controllers.CodeNotFound, "song not found"))
 				return
 			}
 
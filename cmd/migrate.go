@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"music-library/config"
+	"music-library/database"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = "usage: music-library migrate up|down|status|goto <version>|legacy-groups [-drop-column] [-dry-run]"
+
+// runMigrate выполняет подкоманду migrate и возвращает код завершения процесса.
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	dbInstance := database.NewDatabase()
+	if err := dbInstance.Connect(); err != nil {
+		log.WithError(err).Error("Failed to connect to database")
+		return 1
+	}
+	defer dbInstance.Close()
+
+	migrator, err := database.NewMigrator(dbInstance.GetDB())
+	if err != nil {
+		log.WithError(err).Error("Failed to load migrations")
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "goto":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			fmt.Fprintf(os.Stderr, "invalid version %q\n", args[1])
+			return 2
+		}
+		err = migrator.Goto(uint(version))
+	case "status":
+		err = printMigrationStatus(migrator)
+	case "legacy-groups":
+		flags := flag.NewFlagSet("legacy-groups", flag.ContinueOnError)
+		dropColumn := flags.Bool("drop-column", false, `drop songs."group" after verification`)
+		dryRun := flags.Bool("dry-run", false, "roll back all changes and only print the report")
+		if parseErr := flags.Parse(args[1:]); parseErr != nil {
+			return 2
+		}
+		var report *database.LegacyGroupsReport
+		report, err = database.MigrateLegacyGroups(dbInstance.GetDB(), database.LegacyGroupsOptions{
+			DropColumn: *dropColumn,
+			DryRun:     *dryRun,
+		})
+		fmt.Println(report)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Migration command failed")
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(migrator *database.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		if s.Applied {
+			fmt.Fprintf(w, "%06d\t%s\tapplied\t%s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Fprintf(w, "%06d\t%s\tpending\t\n", s.Version, s.Name)
+		}
+	}
+	return w.Flush()
+}
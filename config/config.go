@@ -20,7 +20,44 @@ type Config struct {
 	SERVER_ADDRESS      string
 	TEST_SERVER_ADDRESS string
 	EXTERNAL_API_URL    string
-	LOG_LEVEL           string
+	// Внешний API: срок одной попытки ("2s"), число повторов (2), сколько неудач подряд размыкают
+	// circuit breaker (5, "0" отключает) и сколько он остаётся разомкнутым ("30s")
+	EXTERNAL_API_TIMEOUT           string
+	EXTERNAL_API_RETRIES           string
+	EXTERNAL_API_BREAKER_THRESHOLD string
+	EXTERNAL_API_BREAKER_COOLDOWN  string
+	LOG_LEVEL                      string
+
+	// Storage: "postgres" (по умолчанию) или "memory"
+	STORAGE_DRIVER string
+	// Применять миграции при старте сервера ("true"); иначе только `migrate up`
+	AUTO_MIGRATE string
+	// Сколько удалённые записи хранятся в корзине до очистки, например "720h" (по умолчанию 30 дней)
+	TRASH_RETENTION string
+
+	// Enrichment: источники данных для GET /info в порядке опроса через запятую — database, api, catalog.
+	// У источника можно задать свой таймаут: "database,api:5s,catalog". По умолчанию
+	// "database,api,catalog", api — только если задан EXTERNAL_API_URL
+	ENRICHMENT_PROVIDERS string
+	// Приоритет источников значений полей песни через запятую, от главного: по умолчанию
+	// "manual,catalog,api" — ручные правки не перезаписываются каталогом и внешним API
+	ENRICHMENT_PRECEDENCE string
+	// Через сколько значение поля снова запрашивается у того же источника, например "24h" (по умолчанию); "0" — никогда
+	ENRICHMENT_REFRESH string
+	// Таймаут источника по умолчанию, например "3s"
+	ENRICHMENT_TIMEOUT string
+	// Кэш ответов внешнего API: число записей (1000, "0" отключает кэш), время жизни найденных песен ("1h")
+	// и песен, которых API не знает ("5m")
+	ENRICHMENT_CACHE_SIZE          string
+	ENRICHMENT_CACHE_TTL           string
+	ENRICHMENT_CACHE_NOT_FOUND_TTL string
+	// Файлы и каталоги с JSON/YAML для источника catalog через запятую (по умолчанию song_enrichment.json)
+	ENRICHMENT_CATALOG string
+	// Как часто проверять изменения файлов каталога, например "5s" (по умолчанию); "0" отключает перезагрузку
+	ENRICHMENT_CATALOG_RELOAD string
+
+	// Токен для маршрутов /admin (заголовок "Authorization: Bearer <токен>"); пока он не задан, маршруты отключены
+	ADMIN_TOKEN string
 }
 
 func LoadEnv() (*Config, error) {
@@ -39,6 +76,27 @@ func LoadEnv() (*Config, error) {
 		SERVER_ADDRESS:      os.Getenv("SERVER_ADDRESS"),
 		TEST_SERVER_ADDRESS: os.Getenv("TEST_SERVER_ADDRESS"),
 		EXTERNAL_API_URL:    os.Getenv("EXTERNAL_API_URL"),
-		LOG_LEVEL:           os.Getenv("LOG_LEVEL"),
+
+		EXTERNAL_API_TIMEOUT:           os.Getenv("EXTERNAL_API_TIMEOUT"),
+		EXTERNAL_API_RETRIES:           os.Getenv("EXTERNAL_API_RETRIES"),
+		EXTERNAL_API_BREAKER_THRESHOLD: os.Getenv("EXTERNAL_API_BREAKER_THRESHOLD"),
+		EXTERNAL_API_BREAKER_COOLDOWN:  os.Getenv("EXTERNAL_API_BREAKER_COOLDOWN"),
+
+		LOG_LEVEL:       os.Getenv("LOG_LEVEL"),
+		STORAGE_DRIVER:  os.Getenv("STORAGE_DRIVER"),
+		AUTO_MIGRATE:    os.Getenv("AUTO_MIGRATE"),
+		TRASH_RETENTION: os.Getenv("TRASH_RETENTION"),
+
+		ENRICHMENT_PROVIDERS:           os.Getenv("ENRICHMENT_PROVIDERS"),
+		ENRICHMENT_TIMEOUT:             os.Getenv("ENRICHMENT_TIMEOUT"),
+		ENRICHMENT_PRECEDENCE:          os.Getenv("ENRICHMENT_PRECEDENCE"),
+		ENRICHMENT_REFRESH:             os.Getenv("ENRICHMENT_REFRESH"),
+		ENRICHMENT_CACHE_SIZE:          os.Getenv("ENRICHMENT_CACHE_SIZE"),
+		ENRICHMENT_CACHE_TTL:           os.Getenv("ENRICHMENT_CACHE_TTL"),
+		ENRICHMENT_CACHE_NOT_FOUND_TTL: os.Getenv("ENRICHMENT_CACHE_NOT_FOUND_TTL"),
+		ENRICHMENT_CATALOG:             os.Getenv("ENRICHMENT_CATALOG"),
+		ENRICHMENT_CATALOG_RELOAD:      os.Getenv("ENRICHMENT_CATALOG_RELOAD"),
+
+		ADMIN_TOKEN: os.Getenv("ADMIN_TOKEN"),
 	}, nil
 }
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicateGroupsResponse — группы, чьи названия совпадают после нормализации.
+type DuplicateGroupsResponse struct {
+	Key    string          `json:"key"`
+	Groups []GroupResponse `json:"groups"`
+}
+
+// MergeGroupsRequest — тело POST /admin/groups/merge.
+type MergeGroupsRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+	// SourceIDs — группы, которые сливаются в целевую. Если список пуст,
+	// сливаются все группы с тем же нормализованным названием.
+	SourceIDs []uint `json:"source_ids"`
+}
+
+// ListDuplicateGroups lists groups whose names are equal after normalization
+// @Summary List duplicate groups
+// @Description Find groups whose names only differ in case, whitespace, Unicode form or a leading "The"
+// @Produce json
+// @Success 200 {array} DuplicateGroupsResponse
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/groups/duplicates [get]
+func (h *Handler) ListDuplicateGroups(c *gin.Context) {
+	duplicates, err := h.store.FindDuplicateGroups()
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	response := make([]DuplicateGroupsResponse, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		groups := make([]GroupResponse, 0, len(duplicate.Groups))
+		for _, group := range duplicate.Groups {
+			groups = append(groups, newGroupResponse(group))
+		}
+		response = append(response, DuplicateGroupsResponse{Key: duplicate.Key, Groups: groups})
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// MergeGroups merges duplicate groups into a canonical one
+// @Summary Merge groups
+// @Description Move all songs of the source groups into the target group, delete the source groups and merge songs that became duplicates. Without source_ids all groups with the same normalized name as the target are merged.
+// @Accept json
+// @Produce json
+// @Param merge body MergeGroupsRequest true "Target group and groups to merge into it"
+// @Success 200 {object} repository.MergeReport
+// @Failure 400 {object} Problem "invalid input"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/groups/merge [post]
+func (h *Handler) MergeGroups(c *gin.Context) {
+	var req MergeGroupsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, badRequest("invalid input"))
+		return
+	}
+
+	for _, id := range append([]uint{req.TargetID}, req.SourceIDs...) {
+		if _, err := h.store.GetGroupByID(id); err != nil {
+			abortWithError(c, storeError(err))
+			return
+		}
+	}
+
+	report, err := h.store.MergeGroups(req.TargetID, req.SourceIDs)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// MergeGroupSongs merges duplicate songs inside a group
+// @Summary Merge duplicate songs of a group
+// @Description Merge songs of the group whose names are equal after normalization into the oldest one. Empty fields are filled from the duplicates, the longest text is kept and lookups are summed.
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} repository.MergeReport
+// @Failure 400 {object} Problem "invalid group id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/groups/{id}/merge-songs [post]
+func (h *Handler) MergeGroupSongs(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.store.MergeDuplicateSongs(group.ID)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"music-library/enrichment"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheEntriesResponse — ответ GET /admin/cache/entries.
+type CacheEntriesResponse struct {
+	Items []enrichment.CacheEntry `json:"items"`
+	Page  int                     `json:"page"`
+	Limit int                     `json:"limit"`
+	Total int                     `json:"total"`
+}
+
+// CacheStats returns the state of the enrichment cache
+// @Summary Enrichment cache stats
+// @Description Size, TTLs and hit counters of the cache of external API responses
+// @Produce json
+// @Success 200 {object} enrichment.CacheStats
+// @Failure 404 {object} Problem "cache is disabled"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/cache [get]
+func (h *Handler) CacheStats(c *gin.Context) {
+	if !h.cacheEnabled(c) {
+		return
+	}
+	c.JSON(http.StatusOK, h.cache.Stats())
+}
+
+// ListCacheEntries lists cached external API responses
+// @Summary List enrichment cache entries
+// @Description Unexpired responses of the local cache, most recently used first. Entries with found = false are cached not-found answers. Expired entries kept for serving while the external API is unavailable are not listed and only counted in GET /admin/cache.
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Success 200 {object} CacheEntriesResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 404 {object} Problem "cache is disabled"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/cache/entries [get]
+func (h *Handler) ListCacheEntries(c *gin.Context) {
+	if !h.cacheEnabled(c) {
+		return
+	}
+	page, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
+
+	entries, total := h.cache.Entries((page-1)*limit, limit)
+	c.JSON(http.StatusOK, CacheEntriesResponse{
+		Items: entries,
+		Page:  page,
+		Limit: limit,
+		Total: total,
+	})
+}
+
+// FlushCache empties the enrichment cache
+// @Summary Flush the enrichment cache
+// @Description Remove all cached external API responses, including the shared cache backend if one is configured
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} Problem "cache is disabled"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/cache [delete]
+func (h *Handler) FlushCache(c *gin.Context) {
+	if !h.cacheEnabled(c) {
+		return
+	}
+	if err := h.cache.Flush(c.Request.Context()); err != nil {
+		abortWithError(c, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal,
+			Detail: "failed to flush the cache", Err: err})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{"cache": "flushed"})
+}
+
+// InvalidateCacheEntry removes a song from the enrichment cache
+// @Summary Invalidate a cached song
+// @Description Remove the cached external API responses for a song, so the next /info request asks the API again
+// @Produce json
+// @Param group query string true "Group"
+// @Param song query string true "Song"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} Problem "missing parameters"
+// @Failure 404 {object} Problem "cache is disabled"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/cache/entries [delete]
+func (h *Handler) InvalidateCacheEntry(c *gin.Context) {
+	if !h.cacheEnabled(c) {
+		return
+	}
+	group, song := c.Query("group"), c.Query("song")
+	if group == "" || song == "" {
+		abortWithError(c, badRequest("bad request: missing required parameters"))
+		return
+	}
+	if err := h.cache.Invalidate(c.Request.Context(), group, song); err != nil {
+		abortWithError(c, &APIError{Status: http.StatusInternalServerError, Code: CodeInternal,
+			Detail: "failed to invalidate the cache entry", Err: err})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{"group": group, "song": song, "cache": "invalidated"})
+}
+
+// cacheEnabled отвечает 404, если кэш отключён. Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) cacheEnabled(c *gin.Context) bool {
+	if h.cache == nil {
+		abortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "enrichment cache is disabled"))
+		return false
+	}
+	return true
+}
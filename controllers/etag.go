@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"music-library/models"
+	"music-library/repository"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// songETag — ETag песни. Версия растёт при каждом изменении, поэтому ETag меняется вместе с ней.
+func songETag(song *models.Song) string {
+	return fmt.Sprintf("%q", fmt.Sprintf("%d-%d", song.ID, song.Version))
+}
+
+// etagListContains проверяет, есть ли etag в списке из If-Match или If-None-Match.
+// Слабые ETag сравниваются как сильные: сервис выдаёт только сильные.
+func etagListContains(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified выставляет ETag песни и отвечает 304, если он совпал с If-None-Match.
+// Возвращает true, если ответ уже отправлен.
+func notModified(c *gin.Context, song *models.Song) bool {
+	etag := songETag(song)
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && etagListContains(header, etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatchVersion разбирает If-Match для изменения песни и возвращает версию, которую хранилище
+// должно проверить под блокировкой; 0 — заголовка нет, проверять нечего.
+// Возвращает false, если ответ 412 уже отправлен.
+func ifMatchVersion(c *gin.Context, song *models.Song) (int64, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	if !etagListContains(header, songETag(song)) {
+		abortWithError(c, NewAPIError(http.StatusPreconditionFailed, CodePreconditionFailed, "song has been modified"))
+		return 0, false
+	}
+	// «*» требует только существования песни, версия может быть любой
+	if strings.TrimSpace(header) == "*" {
+		return 0, true
+	}
+	return song.Version, true
+}
+
+// songLoadError отвечает на ошибку загрузки изменяемой песни. Отсутствующая песня при
+// If-Match — это 412, а не 404: условие запроса не выполнено.
+func songLoadError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrNotFound) && c.GetHeader("If-Match") != "" {
+		abortWithError(c, NewAPIError(http.StatusPreconditionFailed, CodePreconditionFailed, "song does not exist"))
+		return
+	}
+	abortWithError(c, storeError(err))
+}
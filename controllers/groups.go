@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"errors"
+	"music-library/models"
+	"music-library/repository"
+	"music-library/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupResponse — группа в ответах API.
+type GroupResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	SongCount int64     `json:"song_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newGroupResponse(group repository.GroupStats) GroupResponse {
+	return GroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		SongCount: group.SongCount,
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+	}
+}
+
+// GroupListResponse — ответ GET /groups.
+type GroupListResponse struct {
+	Items      []GroupResponse `json:"items"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	Total      int64           `json:"total"`
+	TotalPages int64           `json:"total_pages"`
+}
+
+// ListGroups returns groups with their song counts
+// @Summary List groups
+// @Description Retrieve groups ordered by name with the number of songs in each
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Success 200 {object} GroupListResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /groups [get]
+func (h *Handler) ListGroups(c *gin.Context) {
+	page, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
+
+	groups, err := h.store.ListGroups(page, limit)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	total, err := h.store.CountGroups()
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	response := GroupListResponse{
+		Items:      make([]GroupResponse, 0, len(groups)),
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+	for _, group := range groups {
+		response.Items = append(response.Items, newGroupResponse(group))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGroup returns a single group
+// @Summary Get a group
+// @Description Retrieve a group by its ID with the number of its songs
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} GroupResponse
+// @Failure 400 {object} Problem "invalid group id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /groups/{id} [get]
+func (h *Handler) GetGroup(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+	h.respondGroup(c, group)
+}
+
+// RenameGroup changes the name of a group
+// @Summary Rename a group
+// @Description Change the name of a group; the name must not be used by another group
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param group body models.GroupRequest true "New group name"
+// @Success 200 {object} GroupResponse
+// @Failure 400 {object} Problem "invalid input"
+// @Failure 404 {object} Problem "not found"
+// @Failure 409 {object} Problem "group name is already taken"
+// @Failure 422 {object} Problem "validation failed"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /groups/{id} [patch]
+func (h *Handler) RenameGroup(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, badRequest("invalid input"))
+		return
+	}
+	name := utils.CleanName(req.Name)
+	if err := utils.ValidateName(name); err != nil {
+		abortWithError(c, validationError(map[string]string{"name": err.Error()}))
+		return
+	}
+
+	renamed, err := h.store.RenameGroup(group.ID, name)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	h.respondGroup(c, renamed)
+}
+
+// DeleteGroup deletes a group
+// @Summary Delete a group
+// @Description Move a group to the trash. A group that still has songs is only deleted with cascade=true, which moves its songs to the trash too.
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param cascade query bool false "Also delete the songs of the group" default(false)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} Problem "invalid group id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 409 {object} Problem "group still has songs"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /groups/{id} [delete]
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	cascade, err := parseBoolQuery(c, "cascade", false)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
+
+	if err := h.store.DeleteGroup(group.ID, cascade); err != nil {
+		if errors.Is(err, repository.ErrGroupNotEmpty) {
+			abortWithError(c, NewAPIError(http.StatusConflict, CodeGroupNotEmpty, "group still has songs, use cascade=true to delete them too"))
+			return
+		}
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.JSON(http.StatusOK, map[string]interface{}{"id #" + c.Param("id"): "deleted"})
+}
+
+// GetGroupSongs lists the songs of a group
+// @Summary List songs of a group
+// @Description Retrieve the songs of a group; accepts the same filtering, sorting and pagination parameters as GET /songs
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param song query string false "Song"
+// @Param sort query string false "Sort field" Enums(id, song, release_date, created_at) default(id)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Param cursor query string false "Opaque cursor for keyset pagination"
+// @Success 200 {object} SongListResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /groups/{id}/songs [get]
+func (h *Handler) GetGroupSongs(c *gin.Context) {
+	group, ok := h.findGroup(c)
+	if !ok {
+		return
+	}
+
+	filter, err := parseSongFilter(c)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
+	filter.GroupID = group.ID
+
+	h.listSongs(c, filter)
+}
+
+// findGroup загружает группу из параметра пути :id. Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) findGroup(c *gin.Context) (*models.Group, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		abortWithError(c, badRequest("invalid group id"))
+		return nil, false
+	}
+
+	group, err := h.store.GetGroupByID(uint(id))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return nil, false
+	}
+	return group, true
+}
+
+func (h *Handler) respondGroup(c *gin.Context, group *models.Group) {
+	songCount, err := h.store.CountSongs(repository.SongFilter{GroupID: group.ID})
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.JSON(http.StatusOK, GroupResponse{
+		ID:        group.ID,
+		Name:      group.Name,
+		SongCount: songCount,
+		CreatedAt: group.CreatedAt,
+		UpdatedAt: group.UpdatedAt,
+	})
+}
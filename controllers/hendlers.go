@@ -1,258 +1,526 @@
 package controllers
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"music-library/database"
+	"music-library/config"
+	"music-library/enrichment"
+	"music-library/httpclient"
 	"music-library/models"
+	"music-library/repository"
+	"music-library/utils"
 	"net/http"
-	"net/url"
-	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
 )
 
-type SongEnrichment struct {
-	Group       string `json:"group"`
-	Song        string `json:"song"`
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+// Handler содержит зависимости HTTP-обработчиков. Создаётся один раз в cmd/main.go.
+type Handler struct {
+	store repository.SongStore
+	cfg   *config.Config
+	// enrichment — источники данных о песне для GET /info.
+	enrichment *enrichment.Chain
+	// cache — кэш ответов внешнего API; nil, если отключён.
+	cache *enrichment.Cache
+	// lookups объединяет параллельные промахи /info по одной паре группа/песня.
+	lookups singleflight.Group
+}
+
+func NewHandler(store repository.SongStore, cfg *config.Config, chain *enrichment.Chain, cache *enrichment.Cache) *Handler {
+	return &Handler{store: store, cfg: cfg, enrichment: chain, cache: cache}
 }
 
 // GetSongInfo обрабатывает запросы для получения информации о песне и добавляет её в базу данных при отсутствии
 // @Summary Get song details
-// @Description Retrieve detailed information about a song, add to database if not present
+// @Description Retrieve detailed information about a song. A song missing from the library is looked up in the configured enrichment sources (ENRICHMENT_PROVIDERS) in order and added to the library.
 // @Produce json
 // @Param group query string true "Group"
 // @Param song query string true "Song"
+// @Param threshold query number false "Minimum similarity for the did_you_mean suggestion" default(0.3)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} models.SongDetail
-// @Failure 400 {string} string "bad request"
-// @Failure 500 {string} string "internal server error"
+// @Success 304 "not modified"
+// @Failure 400 {object} Problem "bad request"
+// @Failure 404 {object} Problem "song not found, did_you_mean suggests a similar one"
+// @Failure 422 {object} Problem "group or song name is invalid"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 502 {object} Problem "enrichment sources failed"
+// @Failure 503 {object} Problem "external API is unavailable"
+// @Failure 504 {object} Problem "song lookup timed out"
 // @Router /info [get]
-func GetSongInfo(c *gin.Context) {
+func (h *Handler) GetSongInfo(c *gin.Context) {
 	groupName := c.Query("group")
 	songName := c.Query("song")
 
 	if groupName == "" || songName == "" {
-		c.String(http.StatusBadRequest, "bad request: missing required parameters")
+		abortWithError(c, badRequest("bad request: missing required parameters"))
 		return
 	}
 
-	dbInstance := database.NewDatabase()
-	if err := dbInstance.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	threshold, err := parseThreshold(c)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
 		return
 	}
 
-	db := dbInstance.GetDB()
-
-	// Найдём или создадим группу по имени
-	var dbGroup models.Group
-	if err := db.FirstOrCreate(&dbGroup, models.Group{Name: groupName}).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	// Песню, которой нет в библиотеке, создаём под этими названиями, поэтому проверяем их как при POST /songs
+	fields := map[string]string{}
+	addFieldError(fields, "group", utils.ValidateName(utils.CleanName(groupName)))
+	addFieldError(fields, "song", utils.ValidateName(utils.CleanName(songName)))
+	if len(fields) > 0 {
+		abortWithError(c, validationError(fields))
 		return
 	}
 
-	var songRecord models.Song
-	if err := db.Where("group_id = ? AND song = ?", dbGroup.ID, songName).First(&songRecord).Error; err != nil {
-		// Песни нет в БД, попробуем получить из внешнего API
-		songDetail, shouldReturn := GetSongDetailFromAPI(groupName, songName, c)
-		if shouldReturn {
-			return
-		}
-
-		// Парсим дату в формат time.Time
-		parsedDate, err := time.Parse("2006-01-02", songDetail.ReleaseDate)
-		if err != nil {
-			c.String(http.StatusBadRequest, "invalid date format")
-			return
-		}
-
-		newSong := models.Song{
-			GroupID:     dbGroup.ID,
-			Song:        songName,
-			ReleaseDate: parsedDate,
-			Text:        songDetail.Text,
-			Link:        songDetail.Link,
-		}
+	songRecord, err := h.lookupSong(c.Request.Context(), groupName, songName)
+	if errors.Is(err, errSongNotFoundUpstream) {
+		h.respondSongNotFound(c, groupName, songName, threshold)
+		return
+	}
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
 
-		if err := db.Create(&newSong).Error; err != nil {
-			c.String(http.StatusInternalServerError, "internal server error")
-			return
-		}
-		songRecord = newSong
+	// Счётчик влияет только на порядок подсказок, ошибку записывает хранилище, ответ не прерываем
+	_ = h.store.RecordLookup(songRecord.ID)
+	if notModified(c, songRecord) {
+		return
 	}
 
 	// Подготовим SongDetail для ответа
 	songDetail := models.SongDetail{
-		ReleaseDate: songRecord.ReleaseDate.Format("2006-01-02"),
+		ReleaseDate: models.SongField(songRecord, "release_date"),
 		Text:        songRecord.Text,
 		Link:        songRecord.Link,
+		Provenance:  songRecord.Provenance,
 	}
-
-	enrichSongFromJSON(&songDetail, groupName, songName)
 	c.JSON(http.StatusOK, songDetail)
 }
 
-func GetSongDetailFromAPI(group, song string, c *gin.Context) (models.SongDetail, bool) {
-	encodedGroup := url.QueryEscape(group)
-	encodedSong := url.QueryEscape(song)
-	externalAPIUrl := os.Getenv("EXTERNAL_API_URL")
-	if externalAPIUrl == "" {
-		c.String(http.StatusInternalServerError, "internal server error: EXTERNAL_API_URL not set")
-		return models.SongDetail{}, true
+// lookupSong ищет песню по цепочке источников enrichment и сохраняет найденную вне библиотеки.
+// Параллельные запросы одной и той же пары группа/песня объединяются: источники
+// опрашиваются и запись вставляется один раз, результат получают все ожидающие.
+// Каждый запрос ждёт результат не дольше, чем живёт его контекст.
+// Ошибки, кроме errSongNotFoundUpstream, возвращаются как *APIError.
+func (h *Handler) lookupSong(ctx context.Context, groupName, songName string) (*models.Song, error) {
+	key := utils.NameKey(groupName) + "\x00" + utils.NameKey(songName)
+	results := h.lookups.DoChan(key, func() (interface{}, error) {
+		// Результат делят все ожидающие запросы, поэтому отмена первого из них не прерывает опрос;
+		// его срок, если он задан, сохраняется, а в остальном время ограничивают таймауты источников
+		lookupCtx := context.WithoutCancel(ctx)
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			lookupCtx, cancel = context.WithDeadline(lookupCtx, deadline)
+			defer cancel()
+		}
+		found, err := h.enrichment.Lookup(lookupCtx, groupName, songName)
+		if errors.Is(err, enrichment.ErrNotFound) {
+			return nil, errSongNotFoundUpstream
+		}
+		if errors.Is(err, httpclient.ErrCircuitOpen) {
+			return nil, &APIError{Status: http.StatusServiceUnavailable, Code: CodeUpstreamUnavailable,
+				Detail: "external API is unavailable, try again later", Err: err}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, &APIError{Status: http.StatusGatewayTimeout, Code: CodeUpstreamError,
+				Detail: "song lookup timed out", Err: err}
+		}
+		if err != nil {
+			return nil, upstreamError("failed to look up the song", err)
+		}
+		if found.Song.ID != 0 {
+			return h.applyEnrichment(found)
+		}
+
+		// Группу создаём только для песни, которую нашёл один из источников
+		dbGroup, err := h.store.GetOrCreateGroup(groupName)
+		if err != nil {
+			return nil, storeError(err)
+		}
+
+		// Между поиском и вставкой песню мог добавить другой процесс, уникальный индекс это учтёт
+		newSong := *found.Song
+		newSong.GroupID = dbGroup.ID
+		song, _, err := h.store.GetOrCreateSong(&newSong, repository.SystemAuthor)
+		if err != nil {
+			return nil, storeError(err)
+		}
+		return song, nil
+	})
+	var result singleflight.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, &APIError{Status: http.StatusGatewayTimeout, Code: CodeUpstreamError, Detail: "song lookup was cancelled", Err: ctx.Err()}
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Val.(*models.Song), nil
+}
+
+// applyEnrichment записывает в сохранённую песню поля, которые по политике приоритетов
+// пришли из более важных источников.
+func (h *Handler) applyEnrichment(found *enrichment.Result) (*models.Song, error) {
+	if found.Updates.IsEmpty() {
+		return found.Song, nil
 	}
-	apiURL := fmt.Sprintf("%s/info?group=%s&song=%s", externalAPIUrl, encodedGroup, encodedSong)
-	response, err := http.Get(apiURL)
-	if err != nil {
-		c.String(http.StatusInternalServerError, "internal server error: failed to call external API")
-		return models.SongDetail{}, true
+	song, err := h.store.PatchSong(found.Song.ID, found.Updates, repository.SystemAuthor, found.Song.Version)
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		// Песню изменили одновременно с опросом источников: отдаём её как есть, обновим при следующем запросе
+		song, err = h.store.GetSongByID(found.Song.ID)
 	}
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		c.String(http.StatusInternalServerError, "failed to retrieve song details from external API")
-		return models.SongDetail{}, true
+	if err != nil {
+		return nil, storeError(err)
 	}
+	return song, nil
+}
 
-	var apiData models.SongDetail
-	body, err := io.ReadAll(response.Body)
+// respondSongNotFound отвечает 404 и, если в библиотеке есть похожая песня, предлагает её.
+func (h *Handler) respondSongNotFound(c *gin.Context, groupName, songName string, threshold float64) {
+	suggestion, err := h.store.SuggestSong(groupName, songName, threshold)
 	if err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
-		return models.SongDetail{}, true
+		abortWithError(c, storeError(err))
+		return
 	}
+	abortWithError(c, &APIError{
+		Status:     http.StatusNotFound,
+		Code:       CodeNotFound,
+		Detail:     "song not found in the library or any enrichment source",
+		DidYouMean: suggestion,
+	})
+}
+
+// errSongNotFoundUpstream — ни один источник enrichment не нашёл песню.
+var errSongNotFoundUpstream = errors.New("song not found in enrichment sources")
 
-	if err := json.Unmarshal(body, &apiData); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
-		return models.SongDetail{}, true
+// GetSongs retrieves all songs with filtering and pagination
+// @Summary Get all songs
+// @Description Retrieve all songs with optional filtering, sorting and pagination
+// @Produce json
+// @Param group query string false "Group name"
+// @Param group_id query int false "Group ID"
+// @Param song query string false "Song"
+// @Param text query string false "Text (substring)"
+// @Param link query string false "Link"
+// @Param match query string false "Matching for group, song and link" Enums(contains, exact) default(contains)
+// @Param release_date query string false "Exact release date (YYYY-MM-DD)"
+// @Param released_from query string false "Released on or after (YYYY-MM-DD)"
+// @Param released_to query string false "Released on or before (YYYY-MM-DD)"
+// @Param sort query string false "Sort field" Enums(id, song, group, release_date, created_at) default(id)
+// @Param order query string false "Sort direction" Enums(asc, desc) default(asc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Param cursor query string false "Opaque cursor; when present (even empty) keyset pagination is used instead of page"
+// @Param count query bool false "Include total and total_pages (set to false to skip the COUNT query)" default(true)
+// @Success 200 {object} SongListResponse
+// @Header 200 {string} Link "Links to the next and previous pages in cursor mode (RFC 8288)"
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs [get]
+func (h *Handler) GetSongs(c *gin.Context) {
+	filter, err := parseSongFilter(c)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
 	}
 
-	return apiData, false
+	h.listSongs(c, filter)
 }
 
-func GetSongDetailFromJSON(group, song string) (models.SongDetail, error) {
-	jsonFile, err := os.Open("song_enrichment.json")
+// listSongs отдаёт страницу песен по фильтру в режиме страниц или курсоров.
+func (h *Handler) listSongs(c *gin.Context, filter repository.SongFilter) {
+	page, limit, err := parsePagination(c, 10)
 	if err != nil {
-		return models.SongDetail{}, fmt.Errorf("could not open JSON file")
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
 	}
-	defer jsonFile.Close()
 
-	byteValue, _ := io.ReadAll(jsonFile)
-
-	var enrichmentData SongEnrichment
+	withCount, err := parseBoolQuery(c, "count", true)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
 
-	if err := json.Unmarshal(byteValue, &enrichmentData); err != nil {
-		return models.SongDetail{}, fmt.Errorf("could not parse JSON file")
+	var response SongListResponse
+	var songs []models.Song
+	if after, ok := c.GetQuery("cursor"); ok {
+		if songs, ok = h.getSongsByCursor(c, filter, after, limit, &response); !ok {
+			return
+		}
+	} else {
+		if songs, err = h.store.FindSongs(filter, page, limit); err != nil {
+			abortWithError(c, storeError(err))
+			return
+		}
+		response.Page = page
+	}
+	if response.Items, err = h.songResponses(songs); err != nil {
+		abortWithError(c, storeError(err))
+		return
 	}
+	response.Limit = limit
 
-	if enrichmentData.Group == group && enrichmentData.Song == song {
-		return models.SongDetail{
-			ReleaseDate: enrichmentData.ReleaseDate,
-			Text:        enrichmentData.Text,
-			Link:        enrichmentData.Link,
-		}, nil
+	if withCount {
+		total, err := h.store.CountSongs(filter)
+		if err != nil {
+			abortWithError(c, storeError(err))
+			return
+		}
+		response.Total = &total
+		if response.Page != 0 {
+			totalPages := (total + int64(limit) - 1) / int64(limit)
+			response.TotalPages = &totalPages
+		}
 	}
 
-	return models.SongDetail{}, fmt.Errorf("song not found")
+	c.JSON(http.StatusOK, response)
+}
+
+// SongListResponse — ответ GET /songs.
+// В режиме страниц заполнены page и total_pages, в режиме курсоров — next_cursor и prev_cursor.
+// total и total_pages отсутствуют при count=false.
+type SongListResponse struct {
+	Items      []SongResponse `json:"items"`
+	Page       int            `json:"page,omitempty"`
+	Limit      int            `json:"limit"`
+	Total      *int64         `json:"total,omitempty"`
+	TotalPages *int64         `json:"total_pages,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	PrevCursor string         `json:"prev_cursor,omitempty"`
 }
 
-func enrichSongFromJSON(songDetail *models.SongDetail, group, song string) {
-	jsonFile, err := os.Open("song_enrichment.json")
+// getSongsByCursor возвращает песни после курсора, заполняет курсоры ответа и выставляет ссылки
+// на соседние страницы в заголовке Link (RFC 8288). Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) getSongsByCursor(c *gin.Context, filter repository.SongFilter, after string, limit int, response *SongListResponse) ([]models.Song, bool) {
+	page, err := h.store.FindSongsByCursor(filter, after, limit)
 	if err != nil {
-		return
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+			return nil, false
+		}
+		abortWithError(c, storeError(err))
+		return nil, false
 	}
-	defer jsonFile.Close()
-
-	byteValue, _ := io.ReadAll(jsonFile)
 
-	var enrichmentData SongEnrichment
-	if err := json.Unmarshal(byteValue, &enrichmentData); err != nil {
-		return
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, cursorLink(c, page.NextCursor, "next"))
 	}
-
-	if enrichmentData.Group == group && enrichmentData.Song == song {
-		songDetail.ReleaseDate = enrichmentData.ReleaseDate
-		songDetail.Text = enrichmentData.Text
-		songDetail.Link = enrichmentData.Link
+	if page.PrevCursor != "" {
+		links = append(links, cursorLink(c, page.PrevCursor, "prev"))
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
 	}
+
+	response.NextCursor = page.NextCursor
+	response.PrevCursor = page.PrevCursor
+	return page.Songs, true
 }
 
-// GetSongs retrieves all songs with filtering and pagination
-// @Summary Get all songs
-// @Description Retrieve all songs with optional filtering and pagination
+// cursorLink строит элемент заголовка Link на текущий запрос с другим курсором.
+func cursorLink(c *gin.Context, cursor, rel string) string {
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	query.Del("page")
+	u.RawQuery = query.Encode()
+	return fmt.Sprintf("<%s>; rel=\"%s\"", u.RequestURI(), rel)
+}
+
+// SearchHit — песня в результатах полнотекстового поиска. Highlight — фрагмент текста,
+// экранированный для HTML, в котором совпадения выделены тегом <mark>.
+type SearchHit struct {
+	Song      SongResponse `json:"song"`
+	Rank      float64      `json:"rank"`
+	Highlight string       `json:"highlight"`
+}
+
+// SearchResponse — ответ GET /search.
+type SearchResponse struct {
+	Items      []SearchHit `json:"items"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	TotalPages int64       `json:"total_pages"`
+}
+
+// SearchSongs performs full-text search over song titles and lyrics
+// @Summary Full-text search
+// @Description Search song titles and lyrics with Russian and English stemming. Results are ordered by relevance and include highlighted snippets.
 // @Produce json
-// @Param group query string false "Group"
-// @Param song query string false "Song"
-// @Param release_date query string false "Release Date"
-// @Param text query string false "Text"
-// @Param link query string false "Link"
+// @Param q query string true "Search query (web search syntax: words, \"quoted phrases\", or, -exclusions)"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Results per page" default(10)
-// @Success 200 {array} models.Song
-// @Failure 500 {string} string "internal server error"
-// @Router /songs [get]
-func GetSongs(c *gin.Context) {
-	dbInstance := database.NewDatabase()
-	if err := dbInstance.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+// @Success 200 {object} SearchResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /search [get]
+func (h *Handler) SearchSongs(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		abortWithError(c, badRequest("bad request: missing required parameter q"))
 		return
 	}
 
-	db := dbInstance.GetDB()
+	page, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
 
-	var songs []models.Song
+	results, total, err := h.store.SearchSongs(query, page, limit)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	response := SearchResponse{
+		Items:      make([]SearchHit, 0, len(results)),
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	}
+	songs := make([]models.Song, 0, len(results))
+	for _, result := range results {
+		songs = append(songs, result.Song)
+	}
+	responses, err := h.songResponses(songs)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	for i, result := range results {
+		response.Items = append(response.Items, SearchHit{
+			Song:      responses[i],
+			Rank:      result.Rank,
+			Highlight: result.Snippet,
+		})
+	}
 
-	group := c.Query("group")
-	song := c.Query("song")
-	releaseDate := c.Query("release_date")
-	text := c.Query("text")
-	link := c.Query("link")
+	c.JSON(http.StatusOK, response)
+}
 
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "10")
+// FuzzySearch finds groups and songs with names similar to the query
+// @Summary Typo-tolerant name search
+// @Description Find groups and songs whose names are similar to the query (trigram similarity)
+// @Produce json
+// @Param q query string true "Group or song name, possibly misspelled"
+// @Param type query string false "Restrict to groups or songs" Enums(group, song)
+// @Param threshold query number false "Minimum similarity in (0, 1]" default(0.3)
+// @Param limit query int false "Maximum number of results" default(10)
+// @Success 200 {array} repository.SimilarMatch
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /search/fuzzy [get]
+func (h *Handler) FuzzySearch(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		abortWithError(c, badRequest("bad request: missing required parameter q"))
+		return
+	}
 
-	pageNumber, err := strconv.Atoi(page)
-	if err != nil || pageNumber < 1 {
-		pageNumber = 1
+	kind := repository.SimilarityKind(c.Query("type"))
+	if kind != repository.SimilarAll && kind != repository.SimilarGroups && kind != repository.SimilarSongs {
+		abortWithError(c, badRequest("invalid query parameter: type must be group or song"))
+		return
 	}
 
-	limitNumber, err := strconv.Atoi(limit)
-	if err != nil || limitNumber < 1 {
-		limitNumber = 10
+	threshold, err := parseThreshold(c)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
 	}
 
-	query := db.Model(&models.Song{})
+	_, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
 
-	if group != "" {
-		query = query.Where("\"group\" ILIKE ?", "%"+group+"%")
+	matches, err := h.store.FindSimilar(query, kind, threshold, limit)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
 	}
-	if song != "" {
-		query = query.Where("song ILIKE ?", "%"+song+"%")
+
+	c.JSON(http.StatusOK, matches)
+}
+
+// Suggest returns autocomplete suggestions for group and song names
+// @Summary Autocomplete names
+// @Description Suggest group and song names starting with the typed prefix, ranked by prefix match and popularity
+// @Produce json
+// @Param q query string true "Typed prefix"
+// @Param type query string false "Restrict to groups or songs" Enums(group, song)
+// @Param limit query int false "Maximum number of suggestions" default(10)
+// @Success 200 {array} repository.Suggestion
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /suggest [get]
+func (h *Handler) Suggest(c *gin.Context) {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if prefix == "" {
+		abortWithError(c, badRequest("bad request: missing required parameter q"))
+		return
 	}
-	if releaseDate != "" {
-		query = query.Where("release_date = ?", releaseDate)
+
+	kind := repository.SimilarityKind(c.Query("type"))
+	if kind != repository.SimilarAll && kind != repository.SimilarGroups && kind != repository.SimilarSongs {
+		abortWithError(c, badRequest("invalid query parameter: type must be group or song"))
+		return
 	}
-	if text != "" {
-		query = query.Where("text ILIKE ?", "%"+text+"%")
+
+	_, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
 	}
-	if link != "" {
-		query = query.Where("link ILIKE ?", "%"+link+"%")
+
+	suggestions, err := h.store.Suggest(prefix, kind, limit)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
 	}
 
-	offset := (pageNumber - 1) * limitNumber
-	query = query.Offset(offset).Limit(limitNumber)
+	c.JSON(http.StatusOK, suggestions)
+}
 
-	if err := query.Find(&songs).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+// GetSong returns a song by ID
+// @Summary Get a song
+// @Description Retrieve a song by its ID. The ETag header carries the song version: send it back in If-Match to update or delete the song only if nobody changed it in between.
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} SongResponse
+// @Success 304 "not modified"
+// @Failure 400 {object} Problem "invalid song id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs/{id} [get]
+func (h *Handler) GetSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
 		return
 	}
 
-	c.JSON(http.StatusOK, songs)
+	song, err := h.store.GetSongByID(uint(id))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	if notModified(c, song) {
+		return
+	}
+	h.respondSong(c, http.StatusOK, song)
 }
 
 // GetSongTextWithPagination retrieves the text of a song with pagination by verses
@@ -262,28 +530,25 @@ func GetSongs(c *gin.Context) {
 // @Param id path int true "Song ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Verses per page" default(1)
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {string} string "not found"
-// @Failure 500 {string} string "internal server error"
+// @Success 304 "not modified"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
 // @Router /songs/{id}/verses [get]
-func GetSongTextWithPagination(c *gin.Context) {
+func (h *Handler) GetSongTextWithPagination(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.String(http.StatusBadRequest, "invalid song id")
+		abortWithError(c, badRequest("invalid song id"))
 		return
 	}
 
-	dbInstance := database.NewDatabase()
-	if err := dbInstance.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	song, err := h.store.GetSongByID(uint(id))
+	if err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
-
-	db := dbInstance.GetDB()
-
-	var song models.Song
-	if err := db.Unscoped().First(&song, id).Error; err != nil {
-		c.String(http.StatusNotFound, "not found")
+	if notModified(c, song) {
 		return
 	}
 
@@ -301,7 +566,7 @@ func GetSongTextWithPagination(c *gin.Context) {
 
 	totalVerses := len(verses)
 	if totalVerses == 0 {
-		c.String(http.StatusNotFound, "not found")
+		abortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "song has no text"))
 		return
 	}
 
@@ -309,7 +574,7 @@ func GetSongTextWithPagination(c *gin.Context) {
 	endIndex := startIndex + limit
 
 	if startIndex >= totalVerses {
-		c.String(http.StatusNotFound, "no verses found for the requested page")
+		abortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "no verses found for the requested page"))
 		return
 	}
 
@@ -333,64 +598,89 @@ func GetSongTextWithPagination(c *gin.Context) {
 
 // UpdateSong updates an existing song
 // @Summary Update a song
-// @Description Update an existing song by its ID
+// @Description Replace all editable fields of a song by its ID; optional fields missing from the body are cleared. release_date accepts YYYY-MM-DD, DD.MM.YYYY or RFC 3339.
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param song body models.Song true "Updated song data"
-// @Success 200 {object} models.Song
-// @Failure 404 {string} string "not found"
-// @Failure 400 {string} string "invalid input"
+// @Param song body models.ReplaceSongRequest true "New song data"
+// @Param X-Author header string false "Author of the change, stored in the song revision"
+// @Param If-Match header string false "ETag of the song version being edited"
+// @Success 200 {object} SongResponse
+// @Failure 404 {object} Problem "not found"
+// @Failure 400 {object} Problem "invalid input"
+// @Failure 409 {object} Problem "song already exists in group"
+// @Failure 412 {object} Problem "precondition failed"
+// @Failure 422 {object} Problem "validation failed"
 // @Router /songs/{id} [put]
-func UpdateSong(c *gin.Context) {
-	id := c.Param("id")
-	var song models.Song
-
-	dbInstance := database.NewDatabase()
-	if err := dbInstance.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+func (h *Handler) UpdateSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
 		return
 	}
 
-	db := dbInstance.GetDB()
-
-	if err := db.First(&song, id).Error; err != nil {
-		c.String(http.StatusNotFound, "not found")
+	song, err := h.store.GetSongByID(uint(id))
+	if err != nil {
+		songLoadError(c, err)
 		return
 	}
-	if err := c.ShouldBindJSON(&song); err != nil {
-		c.String(http.StatusBadRequest, "invalid input")
+	ifVersion, ok := ifMatchVersion(c, song)
+	if !ok {
 		return
 	}
-	if err := db.Save(&song).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	var req models.ReplaceSongRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, badRequest("invalid input"))
+		return
+	}
+	patch, ok := h.replaceSongPatch(c, req)
+	if !ok {
+		return
+	}
+
+	updated, err := h.store.PatchSong(uint(id), patch, requestAuthor(c), ifVersion)
+	if err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
-	c.JSON(http.StatusOK, song)
+	h.respondSong(c, http.StatusOK, updated)
 }
 
 // DeleteSong deletes a song by ID
 // @Summary Delete a song
-// @Description Delete a song by its ID
+// @Description Move a song to the trash; it can be restored with POST /songs/{id}/restore until the trash is purged
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song version being deleted"
 // @Success 200 {object} map[string]interface{}
-// @Failure 404 {string} string "not found"
-// @Failure 500 {string} string "internal server error"
+// @Failure 404 {object} Problem "not found"
+// @Failure 412 {object} Problem "precondition failed"
+// @Failure 500 {object} Problem "internal server error"
 // @Router /songs/{id} [delete]
-func DeleteSong(c *gin.Context) {
+func (h *Handler) DeleteSong(c *gin.Context) {
 	id := c.Param("id")
-
-	dbInstance := database.NewDatabase()
-	if err := dbInstance.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	songID, err := strconv.Atoi(id)
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
 		return
 	}
 
-	db := dbInstance.GetDB()
+	var ifVersion int64
+	if c.GetHeader("If-Match") != "" {
+		// Без If-Match удаление идемпотентно, с ним песня должна существовать
+		song, err := h.store.GetSongByID(uint(songID))
+		if err != nil {
+			songLoadError(c, err)
+			return
+		}
+		var ok bool
+		if ifVersion, ok = ifMatchVersion(c, song); !ok {
+			return
+		}
+	}
 
-	if err := db.Delete(&models.Song{}, id).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	if err := h.store.DeleteSong(uint(songID), ifVersion); err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
 	c.JSON(http.StatusOK, map[string]interface{}{"id #" + id: "deleted"})
@@ -398,82 +688,106 @@ func DeleteSong(c *gin.Context) {
 
 // CreateSong создает новую песню
 // @Summary Create a new song
-// @Description Create a new song
+// @Description Create a new song; the group is created if it does not exist yet. release_date accepts YYYY-MM-DD, DD.MM.YYYY or RFC 3339.
 // @Accept json
 // @Produce json
 // @Param song body models.NewSongRequest true "New song data"
-// @Success 201 {object} models.Song
-// @Failure 400 {string} string "invalid input"
-// @Failure 500 {string} string "internal server error"
+// @Param X-Author header string false "Author of the change, stored in the song revision"
+// @Success 201 {object} SongResponse
+// @Failure 400 {object} Problem "invalid input"
+// @Failure 409 {object} Problem "song already exists in group"
+// @Failure 422 {object} Problem "validation failed"
+// @Failure 500 {object} Problem "internal server error"
 // @Router /songs [post]
-func CreateSong(c *gin.Context) {
+func (h *Handler) CreateSong(c *gin.Context) {
 	var req models.NewSongRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.String(http.StatusBadRequest, "invalid input")
+		abortWithError(c, badRequest("invalid input"))
 		return
 	}
-
-	db := database.NewDatabase()
-	if err := db.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	fields := map[string]string{}
+	addFieldError(fields, "group", utils.ValidateName(utils.CleanName(req.Group)))
+	releaseDate, err := parseReleaseDate(req.ReleaseDate)
+	addFieldError(fields, "release_date", err)
+	name := utils.CleanName(req.Song)
+	validateSongPatch(repository.SongPatch{Song: &name, Text: &req.Text, Link: &req.Link}, fields)
+	if len(fields) > 0 {
+		abortWithError(c, validationError(fields))
 		return
 	}
 
-	var group models.Group
-	if err := db.GetDB().FirstOrCreate(&group, models.Group{Name: req.Group}).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	group, err := h.store.GetOrCreateGroup(req.Group)
+	if err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
 
 	newSong := models.Song{
-		GroupID: group.ID,
-		Song:    req.Song,
+		GroupID:     group.ID,
+		Song:        req.Song,
+		ReleaseDate: releaseDate,
+		Text:        req.Text,
+		Link:        req.Link,
+		Provenance:  manualProvenance(releaseDate, req.Text, req.Link),
 	}
 
-	if err := db.GetDB().Create(&newSong).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	if _, err := h.store.SaveSong(&newSong, requestAuthor(c)); err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
 
-	c.JSON(http.StatusCreated, newSong)
+	h.respondSong(c, http.StatusCreated, &newSong)
 }
 
 // PartialUpdateSong частично обновляет существующую песню
 // @Summary Partially update a song
-// @Description Update one or multiple fields of an existing song
+// @Description Update one or multiple fields of an existing song. The body is a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json) applied to the song as returned by GET. Only group_id, song, release_date (YYYY-MM-DD, DD.MM.YYYY or RFC 3339), text and link can be changed; null clears release_date, text and link.
 // @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
 // @Produce json
 // @Param id path int true "Song ID"
-// @Param song body map[string]interface{} true "Updated fields"
-// @Success 200 {object} models.Song
-// @Failure 404 {string} string "not found"
-// @Failure 400 {string} string "invalid input"
+// @Param song body models.PatchSongRequest true "Merge patch object, or an array of utils.PatchOperation for application/json-patch+json"
+// @Param X-Author header string false "Author of the change, stored in the song revision"
+// @Param If-Match header string false "ETag of the song version being edited"
+// @Success 200 {object} SongResponse
+// @Failure 404 {object} Problem "not found"
+// @Failure 400 {object} Problem "invalid input"
+// @Failure 409 {object} Problem "song already exists in group or json patch cannot be applied"
+// @Failure 412 {object} Problem "precondition failed"
+// @Failure 415 {object} Problem "unsupported content type"
+// @Failure 422 {object} Problem "validation failed"
 // @Router /songs/{id} [patch]
-func PartialUpdateSong(c *gin.Context) {
-	id := c.Param("id")
-	var updates map[string]interface{}
-
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.String(http.StatusBadRequest, "invalid input")
+func (h *Handler) PartialUpdateSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
 		return
 	}
 
-	db := database.NewDatabase()
-	if err := db.Connect(); err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	current, err := h.store.GetSongByID(uint(id))
+	if err != nil {
+		songLoadError(c, err)
 		return
 	}
-
-	var song models.Song
-	if err := db.GetDB().First(&song, id).Error; err != nil {
-		c.String(http.StatusNotFound, "not found")
+	ifVersion, ok := ifMatchVersion(c, current)
+	if !ok {
+		return
+	}
+	patch, ok := h.parseSongPatch(c, current)
+	if !ok {
+		return
+	}
+	if patch.IsEmpty() {
+		h.respondSong(c, http.StatusOK, current)
 		return
 	}
 
-	if err := db.GetDB().Model(&song).Updates(updates).Error; err != nil {
-		c.String(http.StatusInternalServerError, "internal server error")
+	song, err := h.store.PatchSong(uint(id), patch, requestAuthor(c), ifVersion)
+	if err != nil {
+		abortWithError(c, storeError(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, song)
+	h.respondSong(c, http.StatusOK, song)
 }
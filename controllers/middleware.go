@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// requestIDHeader — заголовок с ID запроса. Пришедший от клиента ID сохраняется, иначе создаётся новый.
+	requestIDHeader = "X-Request-ID"
+	// requestIDKey — ключ ID запроса в gin.Context.
+	requestIDKey = "request_id"
+	// maxRequestIDLength ограничивает длину ID от клиента, чтобы не раздувать логи и ответы.
+	maxRequestIDLength = 128
+)
+
+// RequestID присваивает запросу ID и возвращает его в заголовке X-Request-ID.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// AdminAuth пропускает запрос, только если в заголовке Authorization передан токен администратора.
+// Без настроенного токена маршруты администрирования отключены и отвечают 404.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "admin endpoints are disabled, set ADMIN_TOKEN to enable them"))
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			abortWithError(c, NewAPIError(http.StatusUnauthorized, CodeUnauthorized, "missing or invalid admin token"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"music-library/models"
+	"music-library/repository"
+	"music-library/utils"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Типы тела PATCH /songs/{id}. Обычный application/json разбирается как merge patch.
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// songPatchFields — поля песни, которые клиент может менять через PATCH.
+var songPatchFields = map[string]bool{
+	"group_id":     true,
+	"song":         true,
+	"release_date": true,
+	"text":         true,
+	"link":         true,
+}
+
+// parseSongPatch читает тело PATCH, применяет его к JSON-представлению песни и проверяет
+// изменённые поля. Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) parseSongPatch(c *gin.Context, song *models.Song) (repository.SongPatch, bool) {
+	response, err := h.songResponse(song)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return repository.SongPatch{}, false
+	}
+	before, err := songDocument(response)
+	if err != nil {
+		abortWithError(c, err)
+		return repository.SongPatch{}, false
+	}
+
+	var after interface{}
+	switch contentType := c.ContentType(); contentType {
+	case contentTypeMergePatch, "application/json", "":
+		var patch interface{}
+		if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil {
+			abortWithError(c, badRequest("invalid input: "+err.Error()))
+			return repository.SongPatch{}, false
+		}
+		if _, ok := patch.(map[string]interface{}); !ok {
+			abortWithError(c, badRequest("invalid input: merge patch must be a JSON object"))
+			return repository.SongPatch{}, false
+		}
+		after = utils.MergePatch(before, patch)
+	case contentTypeJSONPatch:
+		var operations []utils.PatchOperation
+		if err := json.NewDecoder(c.Request.Body).Decode(&operations); err != nil {
+			abortWithError(c, badRequest("invalid input: json patch must be an array of operations"))
+			return repository.SongPatch{}, false
+		}
+		// Ошибки патча ErrorHandler превращает в 400 или 409
+		if after, err = utils.ApplyJSONPatch(before, operations); err != nil {
+			abortWithError(c, err)
+			return repository.SongPatch{}, false
+		}
+	default:
+		abortWithError(c, NewAPIError(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+			fmt.Sprintf("unsupported content type %q, use %s or %s", contentType, contentTypeMergePatch, contentTypeJSONPatch)))
+		return repository.SongPatch{}, false
+	}
+
+	document, ok := after.(map[string]interface{})
+	if !ok {
+		abortWithError(c, badRequest("invalid input: patched song must be a JSON object"))
+		return repository.SongPatch{}, false
+	}
+	patch, fields := songPatchFromDocument(before, document)
+	validateSongPatch(patch, fields)
+	if patch.GroupID != nil && fields["group_id"] == "" {
+		h.checkGroupExists(*patch.GroupID, fields)
+	}
+	if len(fields) > 0 {
+		abortWithError(c, validationError(fields))
+		return repository.SongPatch{}, false
+	}
+	return patch, true
+}
+
+// replaceSongPatch превращает тело PUT в патч, задающий все изменяемые поля песни.
+// Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) replaceSongPatch(c *gin.Context, req models.ReplaceSongRequest) (repository.SongPatch, bool) {
+	fields := map[string]string{}
+	name := utils.CleanName(req.Song)
+	releaseDate, err := parseReleaseDate(req.ReleaseDate)
+	addFieldError(fields, "release_date", err)
+	patch := repository.SongPatch{
+		GroupID:     &req.GroupID,
+		Song:        &name,
+		ReleaseDate: &releaseDate,
+		Text:        &req.Text,
+		Link:        &req.Link,
+	}
+	validateSongPatch(patch, fields)
+	h.checkGroupExists(req.GroupID, fields)
+	if len(fields) > 0 {
+		abortWithError(c, validationError(fields))
+		return repository.SongPatch{}, false
+	}
+	return patch, true
+}
+
+// manualProvenance отмечает непустые поля новой песни как заданные вручную.
+func manualProvenance(releaseDate time.Time, text, link string) models.Provenance {
+	provenance := models.Provenance{}
+	source := models.FieldSource{Source: models.SourceManual, FetchedAt: time.Now()}
+	if !releaseDate.IsZero() {
+		provenance["release_date"] = source
+	}
+	if text != "" {
+		provenance["text"] = source
+	}
+	if link != "" {
+		provenance["link"] = source
+	}
+	return provenance
+}
+
+// checkGroupExists добавляет ошибку поля group_id, если группы нет.
+func (h *Handler) checkGroupExists(id uint, fields map[string]string) {
+	if _, err := h.store.GetGroupByID(id); err != nil {
+		fields["group_id"] = fmt.Sprintf("group %d does not exist", id)
+	}
+}
+
+// songDocument — песня в том виде, в котором её отдаёт GET; к нему применяется патч.
+func songDocument(song SongResponse) (map[string]interface{}, error) {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	err = json.Unmarshal(data, &document)
+	return document, err
+}
+
+// songPatchFromDocument сравнивает документ песни до и после патча. Изменённые поля из
+// songPatchFields приводятся к типам модели, изменение любых других полей — ошибка.
+func songPatchFromDocument(before, after map[string]interface{}) (repository.SongPatch, map[string]string) {
+	var patch repository.SongPatch
+	fields := map[string]string{}
+
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		value, present := after[key]
+		if old, existed := before[key]; existed == present && reflect.DeepEqual(old, value) {
+			continue
+		}
+		if !songPatchFields[key] {
+			if _, existed := before[key]; existed {
+				fields[key] = "field is read-only"
+			} else {
+				fields[key] = "unknown field"
+			}
+			continue
+		}
+
+		var err error
+		switch key {
+		case "group_id":
+			var id uint
+			if id, err = patchGroupID(value); err == nil {
+				patch.GroupID = &id
+			}
+		case "song":
+			var name string
+			if name, err = patchString(value, false); err == nil {
+				name = utils.CleanName(name)
+				patch.Song = &name
+			}
+		case "release_date":
+			var date time.Time
+			if date, err = patchDate(value); err == nil {
+				patch.ReleaseDate = &date
+			}
+		case "text":
+			var text string
+			if text, err = patchString(value, true); err == nil {
+				patch.Text = &text
+			}
+		case "link":
+			var link string
+			if link, err = patchString(value, true); err == nil {
+				patch.Link = &link
+			}
+		}
+		if err != nil {
+			fields[key] = err.Error()
+		}
+	}
+	return patch, fields
+}
+
+// patchString проверяет строковое поле; nullable разрешает null (или удаление поля) как пустую строку.
+func patchString(value interface{}, nullable bool) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		if nullable {
+			return "", nil
+		}
+		return "", errors.New("is required")
+	}
+	return "", errors.New("must be a string")
+}
+
+// patchGroupID принимает ID группы числом или строкой с числом.
+func patchGroupID(value interface{}) (uint, error) {
+	switch v := value.(type) {
+	case float64:
+		if v >= 1 && v <= math.MaxUint32 && v == math.Trunc(v) {
+			return uint(v), nil
+		}
+	case string:
+		if id, err := strconv.ParseUint(v, 10, 32); err == nil && id > 0 {
+			return uint(id), nil
+		}
+	case nil:
+		return 0, errors.New("is required")
+	}
+	return 0, errors.New("must be a positive integer")
+}
+
+// parseReleaseDate разбирает дату выпуска из тела запроса; пустая строка — дата неизвестна.
+func parseReleaseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return patchDate(s)
+}
+
+// patchDate принимает дату в формате, который понимает utils.ParseDate; null очищает дату выпуска.
+func patchDate(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		return utils.ParseDate(v)
+	case nil:
+		return time.Time{}, nil
+	}
+	return time.Time{}, errors.New("must be a string in " + utils.DateFormats + " format")
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"errors"
+	"music-library/repository"
+	"music-library/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Коды ошибок API. Код приходит клиенту в поле code и входит в type ответа problem+json.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeNotFound             = "not_found"
+	CodeRevisionNotFound     = "revision_not_found"
+	CodeNotInTrash           = "not_in_trash"
+	CodeSongExists           = "song_exists"
+	CodeGroupNameTaken       = "group_name_taken"
+	CodeGroupNotEmpty        = "group_not_empty"
+	CodePatchConflict        = "patch_conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeDatabaseError        = "database_error"
+	CodeUpstreamError        = "upstream_error"
+	CodeUpstreamUnavailable  = "upstream_unavailable"
+	CodeInternal             = "internal_error"
+	CodeUnauthorized         = "unauthorized"
+)
+
+// problemContentType — тип ответа об ошибке (RFC 7807).
+const problemContentType = "application/problem+json"
+
+// APIError — ошибка обработчика со статусом и кодом для клиента. Обработчик передаёт её
+// в abortWithError, ответ problem+json формирует ErrorHandler.
+type APIError struct {
+	Status int
+	Code   string
+	// Detail — описание для клиента.
+	Detail string
+	// Fields — ошибки отдельных полей запроса.
+	Fields map[string]string
+	// DidYouMean — похожая песня, если запрошенную не нашли.
+	DidYouMean *repository.SongSuggestion
+	// Err — исходная ошибка. Попадает в лог gin, но не в ответ.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Problem — тело ответа об ошибке в формате application/problem+json (RFC 7807).
+type Problem struct {
+	Type     string `json:"type" example:"/problems/not_found"`
+	Title    string `json:"title" example:"Not Found"`
+	Status   int    `json:"status" example:"404"`
+	Detail   string `json:"detail,omitempty" example:"song with ID 5: not found"`
+	Instance string `json:"instance,omitempty" example:"/songs/5"`
+	// Code — машиночитаемый код ошибки.
+	Code      string `json:"code" example:"not_found"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors — ошибки отдельных полей для code = validation_failed.
+	Errors map[string]string `json:"errors,omitempty"`
+	// DidYouMean — похожая песня из библиотеки для 404 от GET /info.
+	DidYouMean *repository.SongSuggestion `json:"did_you_mean,omitempty"`
+}
+
+// NewAPIError создаёт ошибку с кодом и описанием для клиента.
+func NewAPIError(status int, code, detail string) *APIError {
+	return &APIError{Status: status, Code: code, Detail: detail}
+}
+
+func badRequest(detail string) *APIError {
+	return NewAPIError(http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+func validationError(fields map[string]string) *APIError {
+	return &APIError{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed, Detail: "invalid fields", Fields: fields}
+}
+
+// knownErrors — ошибки хранилища и патчей, у которых есть свой статус и код.
+var knownErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{repository.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{repository.ErrRevisionNotFound, http.StatusNotFound, CodeRevisionNotFound},
+	{repository.ErrNotInTrash, http.StatusNotFound, CodeNotInTrash},
+	{repository.ErrSongExists, http.StatusConflict, CodeSongExists},
+	{repository.ErrGroupNameTaken, http.StatusConflict, CodeGroupNameTaken},
+	{repository.ErrGroupNotEmpty, http.StatusConflict, CodeGroupNotEmpty},
+	{repository.ErrVersionMismatch, http.StatusPreconditionFailed, CodePreconditionFailed},
+	{repository.ErrInvalidCursor, http.StatusBadRequest, CodeBadRequest},
+	{utils.ErrPatchConflict, http.StatusConflict, CodePatchConflict},
+	{utils.ErrInvalidPatch, http.StatusBadRequest, CodeBadRequest},
+}
+
+// knownError возвращает APIError для известной ошибки или nil.
+func knownError(err error) *APIError {
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			return &APIError{Status: known.status, Code: known.code, Detail: err.Error(), Err: err}
+		}
+	}
+	return nil
+}
+
+// storeError описывает ошибку хранилища: известные ошибки репозитория получают свой статус,
+// остальные считаются сбоем базы данных.
+func storeError(err error) *APIError {
+	if apiErr := knownError(err); apiErr != nil {
+		return apiErr
+	}
+	return &APIError{Status: http.StatusInternalServerError, Code: CodeDatabaseError, Detail: "database request failed", Err: err}
+}
+
+// upstreamError — внешний API недоступен или ответил ошибкой.
+func upstreamError(detail string, err error) *APIError {
+	return &APIError{Status: http.StatusBadGateway, Code: CodeUpstreamError, Detail: detail, Err: err}
+}
+
+// abortWithError передаёт ошибку в ErrorHandler и прерывает цепочку обработчиков.
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// problemFor собирает тело ответа для ошибки из c.Errors.
+func problemFor(err error) Problem {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		if apiErr = knownError(err); apiErr == nil {
+			apiErr = &APIError{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "internal server error", Err: err}
+		}
+	}
+	return Problem{
+		Type:       "/problems/" + apiErr.Code,
+		Title:      http.StatusText(apiErr.Status),
+		Status:     apiErr.Status,
+		Detail:     apiErr.Detail,
+		Code:       apiErr.Code,
+		Errors:     apiErr.Fields,
+		DidYouMean: apiErr.DidYouMean,
+	}
+}
+
+// ErrorHandler отвечает application/problem+json на ошибку, переданную обработчиком через abortWithError,
+// если обработчик сам ничего не записал.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		problem := problemFor(c.Errors.Last().Err)
+		problem.Instance = c.Request.URL.Path
+		problem.RequestID = c.GetString(requestIDKey)
+		c.Header("Content-Type", problemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// NoRoute отвечает problem+json на запрос к несуществующему пути.
+func NoRoute(c *gin.Context) {
+	abortWithError(c, NewAPIError(http.StatusNotFound, CodeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path))
+}
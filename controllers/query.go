@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"fmt"
+	"music-library/repository"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxPageLimit = 100
+
+// parseSongFilter собирает repository.SongFilter из query-параметров GET /songs.
+func parseSongFilter(c *gin.Context) (repository.SongFilter, error) {
+	filter := repository.SongFilter{
+		Group:  c.Query("group"),
+		Song:   c.Query("song"),
+		Text:   c.Query("text"),
+		Link:   c.Query("link"),
+		Match:  repository.MatchMode(c.DefaultQuery("match", string(repository.MatchContains))),
+		SortBy: repository.SortField(c.DefaultQuery("sort", string(repository.SortByID))),
+	}
+
+	if v := c.Query("group_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil || id == 0 {
+			return filter, fmt.Errorf("group_id must be a positive integer")
+		}
+		filter.GroupID = uint(id)
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	if v := c.Query("release_date"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fmt.Errorf("release_date must be in YYYY-MM-DD format")
+		}
+		filter.ReleasedFrom = &date
+		filter.ReleasedTo = &date
+	}
+	if v := c.Query("released_from"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fmt.Errorf("released_from must be in YYYY-MM-DD format")
+		}
+		filter.ReleasedFrom = &date
+	}
+	if v := c.Query("released_to"); v != "" {
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return filter, fmt.Errorf("released_to must be in YYYY-MM-DD format")
+		}
+		filter.ReleasedTo = &date
+	}
+
+	return filter, filter.Validate()
+}
+
+// parsePagination читает page и limit; limit ограничен maxPageLimit.
+func parsePagination(c *gin.Context, defaultLimit int) (page, limit int, err error) {
+	page, err = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		return 0, 0, fmt.Errorf("page must be a positive integer")
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+	return page, limit, nil
+}
+
+// parseBoolQuery читает логический query-параметр name, если он задан.
+func parseBoolQuery(c *gin.Context, name string, defaultValue bool) (bool, error) {
+	v, ok := c.GetQuery(name)
+	if !ok || v == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
+
+// parseThreshold читает порог похожести threshold из диапазона (0, 1].
+func parseThreshold(c *gin.Context) (float64, error) {
+	v := c.Query("threshold")
+	if v == "" {
+		return repository.DefaultSimilarityThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return 0, fmt.Errorf("threshold must be a number in (0, 1]")
+	}
+	return threshold, nil
+}
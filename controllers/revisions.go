@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"music-library/models"
+	"music-library/repository"
+	"music-library/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authorHeader — заголовок с именем автора изменения. Аутентификации в сервисе нет,
+// поэтому имя берётся как есть и используется только в истории правок.
+const authorHeader = "X-Author"
+
+const anonymousAuthor = "anonymous"
+
+func requestAuthor(c *gin.Context) string {
+	if author := strings.TrimSpace(c.GetHeader(authorHeader)); author != "" {
+		return author
+	}
+	return anonymousAuthor
+}
+
+// RevisionListResponse — ответ GET /songs/{id}/revisions.
+type RevisionListResponse struct {
+	SongID uint               `json:"song_id"`
+	Items  []RevisionResponse `json:"items"`
+}
+
+// RevisionDiffResponse — разница между двумя ревизиями песни.
+type RevisionDiffResponse struct {
+	SongID uint `json:"song_id"`
+	From   int  `json:"from"`
+	To     int  `json:"to"`
+	// Changes — поля, отличающиеся в ревизиях, кроме текста.
+	Changes models.FieldChanges `json:"changes"`
+	// Lines — построчное сравнение текста песни.
+	Lines []utils.DiffLine `json:"lines"`
+}
+
+// ListRevisions returns the change history of a song
+// @Summary List song revisions
+// @Description Retrieve every stored revision of a song, newest first. Each revision holds the author, the action, the changed fields with old and new values and the song state after the change.
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} RevisionListResponse
+// @Failure 400 {object} Problem "invalid song id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs/{id}/revisions [get]
+func (h *Handler) ListRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
+		return
+	}
+	if _, err := h.store.GetSongByID(uint(id)); err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	revisions, err := h.store.ListRevisions(uint(id))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	response := RevisionListResponse{SongID: uint(id), Items: make([]RevisionResponse, 0, len(revisions))}
+	for _, revision := range revisions {
+		response.Items = append(response.Items, newRevisionResponse(revision))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DiffRevisions compares two revisions of a song
+// @Summary Diff song revisions
+// @Description Compare two revisions of a song: changed metadata fields and a line-level diff of the lyrics. By default the latest revision is compared with the previous one.
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param from query int false "Older revision (defaults to to-1; for the first revision the diff is against an empty song and from is 0)"
+// @Param to query int false "Newer revision (defaults to the latest)"
+// @Success 200 {object} RevisionDiffResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 404 {object} Problem "not found"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs/{id}/revisions/diff [get]
+func (h *Handler) DiffRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
+		return
+	}
+	if _, err := h.store.GetSongByID(uint(id)); err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil || to < 0 {
+		abortWithError(c, badRequest("invalid query parameter: to must be a revision number"))
+		return
+	}
+	if to == 0 {
+		revisions, err := h.store.ListRevisions(uint(id))
+		if err != nil {
+			abortWithError(c, storeError(err))
+			return
+		}
+		if len(revisions) == 0 {
+			abortWithError(c, NewAPIError(http.StatusNotFound, CodeRevisionNotFound, "song has no revisions"))
+			return
+		}
+		to = revisions[0].Revision
+	}
+	// Без from сравниваем с предыдущей ревизией, а первую — с пустой песней
+	from := to - 1
+	if value, ok := c.GetQuery("from"); ok {
+		from, err = strconv.Atoi(value)
+		if err != nil || from < 1 {
+			abortWithError(c, badRequest("invalid query parameter: from must be a revision number"))
+			return
+		}
+	}
+
+	fromRev := &models.SongRevision{}
+	if from > 0 {
+		var ok bool
+		if fromRev, ok = h.findRevision(c, uint(id), from); !ok {
+			return
+		}
+	}
+	toRev, ok := h.findRevision(c, uint(id), to)
+	if !ok {
+		return
+	}
+
+	changes := repository.SongChanges(fromRev.Snapshot(), toRev.Snapshot())
+	delete(changes, "text")
+	c.JSON(http.StatusOK, RevisionDiffResponse{
+		SongID:  uint(id),
+		From:    from,
+		To:      to,
+		Changes: changes,
+		Lines:   utils.DiffLines(fromRev.Text, toRev.Text),
+	})
+}
+
+// RestoreRevision rolls a song back to a revision
+// @Summary Restore a song revision
+// @Description Reset the song fields to their state in the given revision. The rollback itself is stored as a new revision.
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param X-Author header string false "Author of the change"
+// @Success 200 {object} SongResponse
+// @Failure 400 {object} Problem "invalid song id"
+// @Failure 404 {object} Problem "not found"
+// @Failure 409 {object} Problem "song already exists in group"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs/{id}/revisions/{rev}/restore [post]
+func (h *Handler) RestoreRevision(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
+		return
+	}
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev < 1 {
+		abortWithError(c, badRequest("invalid revision"))
+		return
+	}
+	if _, err := h.store.GetSongByID(uint(id)); err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	song, err := h.store.RestoreRevision(uint(id), rev, requestAuthor(c))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	h.respondSong(c, http.StatusOK, song)
+}
+
+// findRevision загружает ревизию песни. Возвращает false, если ответ с ошибкой уже отправлен.
+func (h *Handler) findRevision(c *gin.Context, songID uint, revision int) (*models.SongRevision, bool) {
+	rev, err := h.store.GetRevision(songID, revision)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return nil, false
+	}
+	return rev, true
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"music-library/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dateLayout — формат дат без времени в запросах и ответах (ISO 8601).
+const dateLayout = "2006-01-02"
+
+// SongResponse — песня в ответах API.
+type SongResponse struct {
+	ID      uint   `json:"id" example:"1"`
+	GroupID uint   `json:"group_id" example:"1"`
+	Group   string `json:"group" example:"Muse"`
+	Song    string `json:"song" example:"Supermassive Black Hole"`
+	// ReleaseDate — дата выпуска в формате YYYY-MM-DD, null если неизвестна.
+	ReleaseDate *string `json:"release_date" example:"2006-07-16"`
+	Text        string  `json:"text"`
+	Link        string  `json:"link"`
+	// Version растёт при каждом изменении песни, из неё же строится ETag.
+	Version int64 `json:"version" example:"1"`
+	// Provenance — источник и время получения release_date, text и link.
+	Provenance models.Provenance `json:"provenance"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// RevisionResponse — ревизия песни: кто и как её изменил и состояние песни после изменения.
+type RevisionResponse struct {
+	Revision  int                 `json:"revision" example:"2"`
+	CreatedAt time.Time           `json:"created_at"`
+	Author    string              `json:"author" example:"anonymous"`
+	Action    string              `json:"action" enums:"import,create,update,restore,merge"`
+	Changes   models.FieldChanges `json:"changes"`
+	// RestoredFrom — номер ревизии, к которой откатили песню (для action = restore).
+	RestoredFrom int     `json:"restored_from,omitempty"`
+	GroupID      uint    `json:"group_id" example:"1"`
+	Song         string  `json:"song" example:"Supermassive Black Hole"`
+	ReleaseDate  *string `json:"release_date" example:"2006-07-16"`
+	Text         string  `json:"text"`
+	Link         string  `json:"link"`
+}
+
+func newSongResponse(song models.Song, group string) SongResponse {
+	if song.Provenance == nil {
+		song.Provenance = models.Provenance{}
+	}
+	return SongResponse{
+		ID:          song.ID,
+		GroupID:     song.GroupID,
+		Group:       group,
+		Song:        song.Song,
+		ReleaseDate: formatDate(song.ReleaseDate),
+		Text:        song.Text,
+		Link:        song.Link,
+		Version:     song.Version,
+		Provenance:  song.Provenance,
+		CreatedAt:   song.CreatedAt,
+		UpdatedAt:   song.UpdatedAt,
+	}
+}
+
+func newRevisionResponse(rev models.SongRevision) RevisionResponse {
+	return RevisionResponse{
+		Revision:     rev.Revision,
+		CreatedAt:    rev.CreatedAt,
+		Author:       rev.Author,
+		Action:       rev.Action,
+		Changes:      rev.Changes,
+		RestoredFrom: rev.RestoredFrom,
+		GroupID:      rev.GroupID,
+		Song:         rev.Song,
+		ReleaseDate:  formatDate(rev.ReleaseDate),
+		Text:         rev.Text,
+		Link:         rev.Link,
+	}
+}
+
+// formatDate возвращает дату в формате YYYY-MM-DD или nil для нулевой даты.
+func formatDate(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	date := t.Format(dateLayout)
+	return &date
+}
+
+// songResponses собирает ответы для списка песен, загружая названия их групп одним запросом.
+func (h *Handler) songResponses(songs []models.Song) ([]SongResponse, error) {
+	ids := make([]uint, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.GroupID)
+	}
+	names, err := h.store.GroupNames(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]SongResponse, 0, len(songs))
+	for _, song := range songs {
+		responses = append(responses, newSongResponse(song, names[song.GroupID]))
+	}
+	return responses, nil
+}
+
+// songResponse собирает ответ для одной песни.
+func (h *Handler) songResponse(song *models.Song) (SongResponse, error) {
+	responses, err := h.songResponses([]models.Song{*song})
+	if err != nil {
+		return SongResponse{}, err
+	}
+	return responses[0], nil
+}
+
+// respondSong отвечает песней вместе с её ETag.
+func (h *Handler) respondSong(c *gin.Context, status int, song *models.Song) {
+	response, err := h.songResponse(song)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.Header("ETag", songETag(song))
+	c.JSON(status, response)
+}
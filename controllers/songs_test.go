@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"encoding/json"
+	"music-library/models"
+	"music-library/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRouter собирает маршруты /songs поверх хранилища в памяти.
+func newTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewHandler(repository.NewMemoryStore(), nil, nil, nil)
+	r := gin.New()
+	r.Use(RequestID(), ErrorHandler())
+	r.POST("/songs", h.CreateSong)
+	r.GET("/songs/:id", h.GetSong)
+	r.GET("/songs/:id/verses", h.GetSongTextWithPagination)
+	r.PUT("/songs/:id", h.UpdateSong)
+	r.PATCH("/songs/:id", h.PartialUpdateSong)
+	r.DELETE("/songs/:id", h.DeleteSong)
+	return r
+}
+
+// serve выполняет запрос; headers — пары «заголовок, значение».
+func serve(r *gin.Engine, method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeSong(t *testing.T, w *httptest.ResponseRecorder) SongResponse {
+	t.Helper()
+	var song SongResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &song); err != nil {
+		t.Fatalf("invalid song response %s: %v", w.Body, err)
+	}
+	return song
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem response %s: %v", w.Body, err)
+	}
+	return problem
+}
+
+const newSongBody = `{"group":"Muse","song":"Supermassive Black Hole","release_date":"16.07.2006",` +
+	`"text":"Ooh baby, don't you know I suffer?\n\nOoh baby, can you hear me moan?","link":"https://www.youtube.com/watch?v=Xsp3_a-PMTw"}`
+
+func TestSongCRUD(t *testing.T) {
+	r := newTestRouter()
+
+	w := serve(r, http.MethodPost, "/songs", newSongBody)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /songs = %d %s", w.Code, w.Body)
+	}
+	created := decodeSong(t, w)
+	if created.Group != "Muse" || created.ReleaseDate == nil || *created.ReleaseDate != "2006-07-16" || created.Version != 1 {
+		t.Fatalf("created song = %+v", created)
+	}
+	if created.Provenance["text"].Source != models.SourceManual {
+		t.Errorf("text provenance = %+v, want manual", created.Provenance["text"])
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("POST /songs returned no ETag")
+	}
+
+	if w := serve(r, http.MethodPost, "/songs", newSongBody); w.Code != http.StatusConflict {
+		t.Errorf("duplicate POST /songs = %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	path := "/songs/1"
+	w = serve(r, http.MethodGet, path, "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != etag {
+		t.Fatalf("GET %s = %d, ETag %q, want %q", path, w.Code, w.Header().Get("ETag"), etag)
+	}
+	if w := serve(r, http.MethodGet, path, "", "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("GET with a current If-None-Match = %d %s, want 304", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodGet, path+"/verses?page=2", "", "If-None-Match", etag); w.Code != http.StatusNotModified {
+		t.Errorf("GET verses with a current If-None-Match = %d, want 304", w.Code)
+	}
+	w = serve(r, http.MethodGet, path+"/verses?page=2", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "can you hear me moan") {
+		t.Errorf("GET verses page 2 = %d %s", w.Code, w.Body)
+	}
+
+	replace := `{"group_id":1,"song":"Supermassive Black Hole","release_date":"2006-06-19"}`
+	w = serve(r, http.MethodPut, path, replace, "If-Match", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT %s = %d %s", path, w.Code, w.Body)
+	}
+	replaced := decodeSong(t, w)
+	if *replaced.ReleaseDate != "2006-06-19" || replaced.Text != "" || replaced.Link != "" || replaced.Version != 2 {
+		t.Errorf("replaced song = %+v", replaced)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("PUT did not change the ETag")
+	}
+	if w := serve(r, http.MethodGet, path, "", "If-None-Match", etag); w.Code != http.StatusOK {
+		t.Errorf("GET with a stale If-None-Match = %d, want 200", w.Code)
+	}
+	if w := serve(r, http.MethodPut, path, replace, "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale If-Match = %d, want 412", w.Code)
+	}
+
+	if w := serve(r, http.MethodDelete, path, "", "If-Match", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with a stale If-Match = %d, want 412", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, path, ""); w.Code != http.StatusOK {
+		t.Fatalf("DELETE %s = %d %s", path, w.Code, w.Body)
+	}
+	w = serve(r, http.MethodGet, path, "")
+	if w.Code != http.StatusNotFound || decodeProblem(t, w).Code != CodeNotFound {
+		t.Errorf("GET after DELETE = %d %s, want 404", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodPut, path, replace, "If-Match", "*"); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT of a deleted song with If-Match = %d, want 412", w.Code)
+	}
+}
+
+func TestCreateSongValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantCode  string
+		wantField string
+	}{
+		{name: "not JSON", body: `{`, wantCode: CodeBadRequest},
+		{name: "missing song", body: `{"group":"Muse"}`, wantCode: CodeBadRequest},
+		{name: "invalid date", body: `{"group":"Muse","song":"Uprising","release_date":"yesterday"}`, wantCode: CodeValidationFailed, wantField: "release_date"},
+		{name: "invalid link", body: `{"group":"Muse","song":"Uprising","link":"ftp://example.com"}`, wantCode: CodeValidationFailed, wantField: "link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(newTestRouter(), http.MethodPost, "/songs", tt.body)
+			problem := decodeProblem(t, w)
+			if problem.Code != tt.wantCode || w.Code != problem.Status {
+				t.Fatalf("POST /songs = %d %s, want code %s", w.Code, w.Body, tt.wantCode)
+			}
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+			}
+			if tt.wantField != "" && problem.Errors[tt.wantField] == "" {
+				t.Errorf("errors = %v, want an error for %s", problem.Errors, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestPatchSong(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		ifMatch     string
+		wantStatus  int
+		wantCode    string
+		check       func(t *testing.T, song SongResponse)
+	}{
+		{
+			name:       "merge patch as application/json",
+			body:       `{"song":"Uprising","release_date":null}`,
+			ifMatch:    `"1-1"`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, song SongResponse) {
+				if song.Song != "Uprising" || song.ReleaseDate != nil || song.Text == "" || song.Version != 2 {
+					t.Errorf("song = %+v", song)
+				}
+				if source := song.Provenance["release_date"].Source; source != models.SourceManual {
+					t.Errorf("cleared release_date source = %q, want %q", source, models.SourceManual)
+				}
+			},
+		},
+		{
+			name:        "merge patch",
+			contentType: contentTypeMergePatch,
+			body:        `{"link":"https://muse.mu"}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, song SongResponse) {
+				if song.Link != "https://muse.mu" || song.Version != 2 {
+					t.Errorf("song = %+v", song)
+				}
+			},
+		},
+		{
+			name:        "json patch",
+			contentType: contentTypeJSONPatch,
+			body:        `[{"op":"test","path":"/version","value":1},{"op":"replace","path":"/text","value":"new text"},{"op":"remove","path":"/link"}]`,
+			ifMatch:     `"1-1"`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, song SongResponse) {
+				if song.Text != "new text" || song.Link != "" || song.Version != 2 {
+					t.Errorf("song = %+v", song)
+				}
+			},
+		},
+		{
+			name:       "empty patch keeps the version",
+			body:       `{}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, song SongResponse) {
+				if song.Version != 1 {
+					t.Errorf("version = %d, want 1", song.Version)
+				}
+			},
+		},
+		{
+			name:        "failed json patch test",
+			contentType: contentTypeJSONPatch,
+			body:        `[{"op":"test","path":"/song","value":"Hysteria"}]`,
+			wantStatus:  http.StatusConflict,
+			wantCode:    CodePatchConflict,
+		},
+		{
+			name:        "read-only field",
+			contentType: contentTypeJSONPatch,
+			body:        `[{"op":"replace","path":"/version","value":7}]`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantCode:    CodeValidationFailed,
+		},
+		{
+			name:       "stale If-Match",
+			body:       `{"song":"Uprising"}`,
+			ifMatch:    `"1-0"`,
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   CodePreconditionFailed,
+		},
+		{
+			name:       "merge patch must be an object",
+			body:       `["song"]`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeBadRequest,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "text/plain",
+			body:        `song=Uprising`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    CodeUnsupportedMediaType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter()
+			if w := serve(r, http.MethodPost, "/songs", newSongBody); w.Code != http.StatusCreated {
+				t.Fatalf("POST /songs = %d %s", w.Code, w.Body)
+			}
+			headers := []string{}
+			if tt.contentType != "" {
+				headers = append(headers, "Content-Type", tt.contentType)
+			}
+			if tt.ifMatch != "" {
+				headers = append(headers, "If-Match", tt.ifMatch)
+			}
+			w := serve(r, http.MethodPatch, "/songs/1", tt.body, headers...)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("PATCH = %d %s, want %d", w.Code, w.Body, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				if problem := decodeProblem(t, w); problem.Code != tt.wantCode {
+					t.Errorf("code = %s, want %s", problem.Code, tt.wantCode)
+				}
+				if w := serve(r, http.MethodGet, "/songs/1", ""); decodeSong(t, w).Version != 1 {
+					t.Errorf("rejected patch changed the song: %s", w.Body)
+				}
+				return
+			}
+			song := decodeSong(t, w)
+			if etag := w.Header().Get("ETag"); etag != songETag(&models.Song{ID: song.ID, Version: song.Version}) {
+				t.Errorf("ETag = %q does not match version %d", etag, song.Version)
+			}
+			tt.check(t, song)
+		})
+	}
+}
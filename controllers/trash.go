@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"fmt"
+	"music-library/repository"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTrashRetention — срок хранения записей в корзине, если TRASH_RETENTION не задан.
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashListResponse — ответ GET /trash.
+type TrashListResponse struct {
+	Items      []repository.TrashItem `json:"items"`
+	Page       int                    `json:"page"`
+	Limit      int                    `json:"limit"`
+	Total      int64                  `json:"total"`
+	TotalPages int64                  `json:"total_pages"`
+}
+
+// ListTrash returns deleted songs and groups
+// @Summary List trash
+// @Description Retrieve deleted songs and groups that can still be restored, most recently deleted first
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Results per page" default(10)
+// @Success 200 {object} TrashListResponse
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /trash [get]
+func (h *Handler) ListTrash(c *gin.Context) {
+	page, limit, err := parsePagination(c, 10)
+	if err != nil {
+		abortWithError(c, badRequest("invalid query parameter: "+err.Error()))
+		return
+	}
+
+	items, total, err := h.store.ListTrash(page, limit)
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, TrashListResponse{
+		Items:      items,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// RestoreSong restores a deleted song
+// @Summary Restore a song
+// @Description Restore a song from the trash; its group is restored too if it was deleted
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} SongResponse
+// @Failure 400 {object} Problem "invalid song id"
+// @Failure 404 {object} Problem "not found in trash"
+// @Failure 409 {object} Problem "song already exists in group"
+// @Failure 500 {object} Problem "internal server error"
+// @Router /songs/{id}/restore [post]
+func (h *Handler) RestoreSong(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		abortWithError(c, badRequest("invalid song id"))
+		return
+	}
+
+	song, err := h.store.RestoreSong(uint(id))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	h.respondSong(c, http.StatusOK, song)
+}
+
+// PurgeTrash permanently removes old items from the trash
+// @Summary Purge trash
+// @Description Permanently delete songs and groups that were deleted longer ago than the retention period (TRASH_RETENTION, 30 days by default). A group is only purged once none of its songs remain.
+// @Produce json
+// @Param older_than query string false "Retention period overriding TRASH_RETENTION, e.g. 720h"
+// @Success 200 {object} repository.PurgeReport
+// @Failure 400 {object} Problem "invalid query parameter"
+// @Failure 500 {object} Problem "internal server error"
+// @Failure 401 {object} Problem "missing or invalid admin token"
+// @Security AdminToken
+// @Router /admin/trash/purge [post]
+func (h *Handler) PurgeTrash(c *gin.Context) {
+	retention, err := h.trashRetention()
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+	if v := c.Query("older_than"); v != "" {
+		retention, err = time.ParseDuration(v)
+		if err != nil || retention < 0 {
+			abortWithError(c, badRequest("invalid query parameter: older_than must be a non-negative duration such as 720h"))
+			return
+		}
+	}
+
+	report, err := h.store.PurgeTrash(time.Now().Add(-retention))
+	if err != nil {
+		abortWithError(c, storeError(err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// trashRetention возвращает срок хранения в корзине из конфигурации.
+func (h *Handler) trashRetention() (time.Duration, error) {
+	if h.cfg == nil || h.cfg.TRASH_RETENTION == "" {
+		return defaultTrashRetention, nil
+	}
+	retention, err := time.ParseDuration(h.cfg.TRASH_RETENTION)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid TRASH_RETENTION %q", h.cfg.TRASH_RETENTION)
+	}
+	return retention, nil
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"music-library/repository"
+	"music-library/utils"
+)
+
+// validateSongPatch проверяет новые значения полей песни и дописывает ошибки в fields.
+// Поля, у которых ошибка уже есть, не перепроверяются.
+func validateSongPatch(patch repository.SongPatch, fields map[string]string) {
+	if patch.Song != nil {
+		addFieldError(fields, "song", utils.ValidateName(*patch.Song))
+	}
+	if patch.Text != nil {
+		addFieldError(fields, "text", utils.ValidateText(*patch.Text))
+	}
+	if patch.Link != nil {
+		addFieldError(fields, "link", utils.ValidateLink(*patch.Link))
+	}
+}
+
+// addFieldError записывает ошибку поля, если её ещё нет.
+func addFieldError(fields map[string]string, field string, err error) {
+	if err != nil && fields[field] == "" {
+		fields[field] = err.Error()
+	}
+}
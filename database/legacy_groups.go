@@ -0,0 +1,187 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// LegacyGroupsOptions управляет переносом старой колонки songs."group" в таблицу groups.
+type LegacyGroupsOptions struct {
+	// DropColumn удаляет колонку "group" после успешной проверки.
+	DropColumn bool
+	// DryRun выполняет перенос в транзакции и откатывает её, возвращая только отчёт.
+	DryRun bool
+}
+
+// LegacyGroupsReport описывает, что изменил перенос.
+type LegacyGroupsReport struct {
+	LegacyColumnFound bool
+	LegacyGroups      int64 // различных непустых значений "group"
+	GroupsCreated     int64
+	SongsBackfilled   int64
+	SongsMismatched   int64 // песни, у которых group_id не соответствует значению "group"
+	SongsDuplicated   int64 // песни без group_id: в их группе уже есть песня с тем же названием
+	SongsWithoutGroup int64 // песни без group_id и без значения "group"
+	ColumnDropped     bool
+	DryRun            bool
+}
+
+func (r LegacyGroupsReport) String() string {
+	if !r.LegacyColumnFound {
+		return "legacy column songs.\"group\" not found, nothing to migrate"
+	}
+	return fmt.Sprintf(
+		"legacy groups: %d, groups created: %d, songs backfilled: %d, mismatched: %d, duplicated: %d, without group: %d, column dropped: %t, dry run: %t",
+		r.LegacyGroups, r.GroupsCreated, r.SongsBackfilled, r.SongsMismatched, r.SongsDuplicated, r.SongsWithoutGroup, r.ColumnDropped, r.DryRun,
+	)
+}
+
+// ErrLegacyGroupsUnverified возвращается, если после переноса остались песни,
+// чья группа не совпадает со старым значением, и колонку удалять нельзя.
+var ErrLegacyGroupsUnverified = errors.New("legacy group verification failed")
+
+var errDryRun = errors.New("dry run")
+
+// Выражения над songs."group": ключ для поиска дубликатов (как utils.NameKey)
+// и название в том виде, в каком его сохраняет API (как utils.CleanName).
+const (
+	legacyGroupKey  = `music_name_key(songs."group")`
+	legacyGroupName = `btrim(regexp_replace(normalize(songs."group", NFC), '\s+', ' ', 'g'))`
+)
+
+// MigrateLegacyGroups создаёт записи groups из различных значений songs."group",
+// заполняет songs.group_id и, если задано, удаляет старую колонку после проверки.
+// Значения сравниваются по ключу music_name_key, как при создании песен через API:
+// "Muse", " muse" и "The Muse" попадают в одну группу, существующую или новую,
+// названную самым частым вариантом. Песня, название которой в группе уже занято,
+// остаётся без group_id и учитывается в SongsDuplicated.
+// Повторный запуск безопасен: существующие группы и заполненные group_id не трогаются.
+func MigrateLegacyGroups(db *gorm.DB, opts LegacyGroupsOptions) (*LegacyGroupsReport, error) {
+	report := &LegacyGroupsReport{DryRun: opts.DryRun}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+
+		report.LegacyColumnFound = tx.Migrator().HasColumn("songs", "group")
+		if !report.LegacyColumnFound {
+			return nil
+		}
+		for _, column := range []struct{ table, name string }{
+			{"songs", "group_id"}, {"songs", "normalized_song"}, {"songs", "deleted_at"}, {"groups", "normalized_name"},
+		} {
+			if !tx.Migrator().HasColumn(column.table, column.name) {
+				return fmt.Errorf("%s.%s does not exist, run `migrate up` first", column.table, column.name)
+			}
+		}
+
+		if err := tx.Raw(`SELECT COUNT(DISTINCT ` + legacyGroupKey + `) FROM songs WHERE ` + legacyGroupKey + ` <> ''`).
+			Scan(&report.LegacyGroups).Error; err != nil {
+			return fmt.Errorf("failed to count legacy groups: %w", err)
+		}
+
+		if err := tx.Exec(`UPDATE groups SET normalized_name = music_name_key(name) WHERE normalized_name IS NULL`).Error; err != nil {
+			return fmt.Errorf("failed to normalize group names: %w", err)
+		}
+		created := tx.Exec(`
+			INSERT INTO groups (name, normalized_name, created_at, updated_at)
+			SELECT DISTINCT ON (key) name, key, now(), now()
+			FROM (
+				SELECT ` + legacyGroupName + ` AS name, ` + legacyGroupKey + ` AS key, COUNT(*) AS uses
+				FROM songs
+				WHERE ` + legacyGroupKey + ` <> ''
+				GROUP BY 1, 2
+			) AS variants
+			WHERE NOT EXISTS (SELECT 1 FROM groups WHERE groups.normalized_name = variants.key)
+			ORDER BY key, uses DESC, name
+			ON CONFLICT (name) DO NOTHING`)
+		if created.Error != nil {
+			return fmt.Errorf("failed to create groups: %w", created.Error)
+		}
+		report.GroupsCreated = created.RowsAffected
+
+		// Группа для ключа — неудалённая с наименьшим ID. Из песен с одинаковым названием
+		// переносится только первая, и только если в группе такого названия ещё нет.
+		backfilled := tx.Exec(`
+			WITH keyed_groups AS (
+				SELECT DISTINCT ON (normalized_name) id, normalized_name
+				FROM groups
+				ORDER BY normalized_name, deleted_at IS NOT NULL, id
+			)
+			UPDATE songs
+			SET group_id = keyed_groups.id
+			FROM keyed_groups
+			WHERE songs.group_id IS NULL
+			  AND keyed_groups.normalized_name = ` + legacyGroupKey + `
+			  AND (songs.deleted_at IS NOT NULL OR (
+			      NOT EXISTS (
+			          SELECT 1 FROM songs AS taken
+			          WHERE taken.group_id = keyed_groups.id
+			            AND taken.normalized_song = songs.normalized_song
+			            AND taken.deleted_at IS NULL)
+			      AND NOT EXISTS (
+			          SELECT 1 FROM songs AS earlier
+			          WHERE earlier.group_id IS NULL
+			            AND music_name_key(earlier."group") = keyed_groups.normalized_name
+			            AND earlier.normalized_song = songs.normalized_song
+			            AND earlier.deleted_at IS NULL
+			            AND earlier.id < songs.id)))`)
+		if backfilled.Error != nil {
+			return fmt.Errorf("failed to backfill songs.group_id: %w", backfilled.Error)
+		}
+		report.SongsBackfilled = backfilled.RowsAffected
+
+		if err := tx.Raw(`
+			SELECT COUNT(*)
+			FROM songs
+			LEFT JOIN groups ON groups.id = songs.group_id
+			WHERE ` + legacyGroupKey + ` <> ''
+			  AND (groups.id IS NULL OR groups.normalized_name <> ` + legacyGroupKey + `)`).
+			Scan(&report.SongsMismatched).Error; err != nil {
+			return fmt.Errorf("failed to verify songs: %w", err)
+		}
+		if err := tx.Raw(`
+			SELECT COUNT(*)
+			FROM songs
+			WHERE group_id IS NULL AND ` + legacyGroupKey + ` <> ''
+			  AND EXISTS (SELECT 1 FROM groups WHERE groups.normalized_name = ` + legacyGroupKey + `)`).
+			Scan(&report.SongsDuplicated).Error; err != nil {
+			return fmt.Errorf("failed to verify songs: %w", err)
+		}
+		if err := tx.Raw(`
+			SELECT COUNT(*)
+			FROM songs
+			WHERE group_id IS NULL AND COALESCE(` + legacyGroupKey + `, '') = ''`).
+			Scan(&report.SongsWithoutGroup).Error; err != nil {
+			return fmt.Errorf("failed to verify songs: %w", err)
+		}
+
+		if opts.DropColumn {
+			if report.SongsMismatched > 0 {
+				return fmt.Errorf("%w: %d songs do not match their legacy group", ErrLegacyGroupsUnverified, report.SongsMismatched)
+			}
+			if err := tx.Exec(`DROP INDEX IF EXISTS idx_song_group`).Error; err != nil {
+				return fmt.Errorf("failed to drop legacy index: %w", err)
+			}
+			if err := tx.Exec(`ALTER TABLE songs DROP COLUMN "group"`).Error; err != nil {
+				return fmt.Errorf("failed to drop legacy column: %w", err)
+			}
+			report.ColumnDropped = true
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return report, fmt.Errorf("legacy group migration failed: %w", err)
+	}
+
+	log.Printf("Legacy group migration: %s\n", report)
+	return report, nil
+}
@@ -1,15 +1,297 @@
 package database
 
 import (
+	"embed"
+	"fmt"
+	"io/fs"
 	"log"
-	"music-library/models"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-func Migrate(db *gorm.DB) {
-	err := db.AutoMigrate(&models.Song{})
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID — ключ advisory-блокировки, чтобы два процесса не применяли миграции одновременно.
+const migrationLockID = 7245001
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration — одна версия схемы из каталога database/migrations.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus описывает состояние версии в schema_migrations.
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator применяет и откатывает пронумерованные SQL-миграции по порядку.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[uint]schemaMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	var rows []schemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	applied := make(map[uint]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status возвращает все известные миграции с отметкой о применении.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Version возвращает номер последней применённой миграции (0, если ничего не применено).
+func (m *Migrator) Version() (uint, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	var version uint
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Pending возвращает миграции, которые ещё не применены.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up применяет все неприменённые миграции.
+func (m *Migrator) Up() error {
+	if len(m.migrations) == 0 {
+		return nil
+	}
+	return m.Goto(m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down откатывает последнюю применённую миграцию.
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.rollback(m.migrations[i])
+		}
+	}
+	log.Println("No migrations to roll back")
+	return nil
+}
+
+// Goto применяет или откатывает миграции так, чтобы схема оказалась на версии target.
+// target = 0 откатывает все миграции.
+func (m *Migrator) Goto(target uint) error {
+	if target != 0 && !m.known(target) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > target {
+			if err := m.rollback(migration); err != nil {
+				return err
+			}
+		}
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= target {
+			if err := m.apply(migration); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) known(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			// Миграцию уже применил другой процесс, пока мы ждали блокировку.
+			return nil
+		}
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return err
+		}
+		return tx.Create(&schemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %d_%s up failed: %w", migration.Version, migration.Name, err)
+	}
+	log.Printf("Applied migration %d_%s\n", migration.Version, migration.Name)
+	return nil
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+			return err
+		}
+		result := tx.Where("version = ?", migration.Version).Delete(&schemaMigration{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Уже откачена другим процессом.
+			return nil
+		}
+		return tx.Exec(migration.Down).Error
+	})
 	if err != nil {
-		log.Fatal("Migration failed: ", err)
+		return fmt.Errorf("migration %d_%s down failed: %w", migration.Version, migration.Name, err)
 	}
+	log.Printf("Rolled back migration %d_%s\n", migration.Version, migration.Name)
+	return nil
 }
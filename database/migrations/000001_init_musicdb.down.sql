@@ -1 +1 @@
-DROP DATABASE IF EXISTS musicdb;
\ No newline at end of file
+DROP TABLE IF EXISTS groups;
@@ -1,4 +1,4 @@
-CREATE TABLE songs (
+CREATE TABLE IF NOT EXISTS songs (
     id BIGSERIAL PRIMARY KEY,
     "group" VARCHAR(255) NOT NULL,
     song VARCHAR(255) NOT NULL,
@@ -7,4 +7,10 @@ CREATE TABLE songs (
     link VARCHAR(1000)
 );
 
-CREATE INDEX idx_song_group ON songs ("group", song);
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'songs' AND column_name = 'group') THEN
+        CREATE INDEX IF NOT EXISTS idx_song_group ON songs ("group", song);
+    END IF;
+END $$;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_songs_deleted_at;
+DROP INDEX IF EXISTS idx_songs_release_date;
+DROP INDEX IF EXISTS idx_songs_song;
+DROP INDEX IF EXISTS idx_songs_group_id;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS group_id,
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
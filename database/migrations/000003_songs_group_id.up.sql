@@ -0,0 +1,20 @@
+-- Приводим таблицу songs к схеме models.Song: связь с groups через group_id.
+-- Старая колонка "group" остаётся до переноса данных, но перестаёт быть обязательной.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS group_id BIGINT REFERENCES groups (id);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns
+               WHERE table_schema = current_schema() AND table_name = 'songs' AND column_name = 'group') THEN
+        ALTER TABLE songs ALTER COLUMN "group" DROP NOT NULL;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_songs_group_id ON songs (group_id);
+CREATE INDEX IF NOT EXISTS idx_songs_song ON songs (song);
+CREATE INDEX IF NOT EXISTS idx_songs_release_date ON songs (release_date);
+CREATE INDEX IF NOT EXISTS idx_songs_deleted_at ON songs (deleted_at);
@@ -0,0 +1,11 @@
+-- Полнотекстовый поиск по названию и тексту песни.
+-- Конфигурация russian стеммит кириллицу русским стеммером, а латиницу английским,
+-- поэтому подходит для смешанного каталога.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('russian', coalesce(song, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(text, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN (search_vector);
@@ -0,0 +1,3 @@
+-- Расширение pg_trgm не удаляем: им могут пользоваться другие объекты базы.
+DROP INDEX IF EXISTS idx_songs_song_trgm;
+DROP INDEX IF EXISTS idx_groups_name_trgm;
@@ -0,0 +1,5 @@
+-- Нечёткий поиск по названиям групп и песен (pg_trgm).
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_groups_name_trgm ON groups USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_songs_song_trgm ON songs USING GIN (song gin_trgm_ops);
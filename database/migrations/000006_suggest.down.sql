@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_songs_song_prefix;
+DROP INDEX IF EXISTS idx_groups_name_prefix;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS lookups;
@@ -0,0 +1,5 @@
+-- Подсказки по префиксу: индексы по lower(name) для LIKE 'префикс%' и счётчик обращений к песне.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS lookups BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_groups_name_prefix ON groups (lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_songs_song_prefix ON songs (lower(song) text_pattern_ops);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_songs_normalized_song;
+DROP INDEX IF EXISTS idx_groups_normalized_name;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS normalized_song;
+ALTER TABLE groups DROP COLUMN IF EXISTS normalized_name;
+
+DROP FUNCTION IF EXISTS music_name_key(TEXT);
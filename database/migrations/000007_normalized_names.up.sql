@@ -0,0 +1,20 @@
+-- Ключи нормализованных названий для поиска дубликатов групп и песен.
+-- Функция повторяет utils.NameKey: NFKC, нижний регистр, схлопывание пробелов, артикль "The".
+CREATE OR REPLACE FUNCTION music_name_key(name TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE STRICT PARALLEL SAFE AS $$
+    SELECT CASE WHEN k ~ '^the .' THEN substr(k, 5) ELSE k END
+    FROM (
+        SELECT regexp_replace(
+                   btrim(regexp_replace(lower(normalize(name, NFKC)), '\s+', ' ', 'g')),
+                   ', the$', '') AS k
+    ) AS keys
+$$;
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS normalized_name TEXT;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS normalized_song TEXT;
+
+UPDATE groups SET normalized_name = music_name_key(name) WHERE normalized_name IS NULL;
+UPDATE songs SET normalized_song = music_name_key(song) WHERE normalized_song IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_groups_normalized_name ON groups (normalized_name);
+CREATE INDEX IF NOT EXISTS idx_songs_normalized_song ON songs (group_id, normalized_song);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_songs_group_normalized_song;
+CREATE INDEX IF NOT EXISTS idx_songs_normalized_song ON songs (group_id, normalized_song);
@@ -0,0 +1,33 @@
+-- Перед созданием уникального индекса сливаем уже существующие дубликаты песен
+-- в запись с наименьшим ID, как это делает POST /admin/groups/{id}/merge-songs.
+WITH duplicates AS (
+    SELECT id, min(id) OVER (PARTITION BY group_id, normalized_song) AS keeper_id
+    FROM songs
+    WHERE group_id IS NOT NULL AND normalized_song IS NOT NULL
+), merged AS (
+    SELECT d.keeper_id,
+           sum(s.lookups) AS lookups,
+           (array_agg(s.text ORDER BY length(coalesce(s.text, '')) DESC, s.id))[1] AS text,
+           (array_agg(s.link ORDER BY s.id) FILTER (WHERE coalesce(s.link, '') <> ''))[1] AS link,
+           (array_agg(s.release_date ORDER BY s.id) FILTER (WHERE s.release_date > '0001-01-01 00:00:00+00'))[1] AS release_date
+    FROM duplicates d
+    JOIN songs s ON s.id = d.id
+    GROUP BY d.keeper_id
+    HAVING count(*) > 1
+)
+UPDATE songs
+SET lookups = merged.lookups,
+    text = merged.text,
+    link = coalesce(merged.link, songs.link),
+    release_date = coalesce(merged.release_date, songs.release_date)
+FROM merged
+WHERE songs.id = merged.keeper_id;
+
+DELETE FROM songs
+USING songs AS keeper
+WHERE keeper.group_id = songs.group_id
+  AND keeper.normalized_song = songs.normalized_song
+  AND keeper.id < songs.id;
+
+DROP INDEX IF EXISTS idx_songs_normalized_song;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_songs_group_normalized_song ON songs (group_id, normalized_song);
@@ -0,0 +1,11 @@
+-- Полный уникальный индекс не допускает дубликатов в корзине: удаляем их окончательно.
+DELETE FROM songs
+USING songs AS other
+WHERE songs.deleted_at IS NOT NULL
+  AND other.group_id = songs.group_id
+  AND other.normalized_song = songs.normalized_song
+  AND other.id <> songs.id
+  AND (other.deleted_at IS NULL OR other.id < songs.id);
+
+DROP INDEX IF EXISTS idx_songs_group_normalized_song;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_songs_group_normalized_song ON songs (group_id, normalized_song);
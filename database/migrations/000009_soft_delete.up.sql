@@ -0,0 +1,5 @@
+-- Удалённые песни остаются в корзине, поэтому уникальность названия в группе
+-- проверяется только среди неудалённых записей.
+DROP INDEX IF EXISTS idx_songs_group_normalized_song;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_songs_group_normalized_song ON songs (group_id, normalized_song)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,25 @@
+-- История изменений песен: каждая ревизия хранит состояние песни после изменения.
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    song_id BIGINT NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    author TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    restored_from INTEGER NOT NULL DEFAULT 0,
+    group_id BIGINT,
+    song TEXT NOT NULL,
+    release_date DATE,
+    text TEXT,
+    link TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_song_revisions_song_revision ON song_revisions (song_id, revision);
+
+-- Уже существующие песни получают первую ревизию с текущим состоянием,
+-- чтобы к нему можно было вернуться после следующих изменений.
+INSERT INTO song_revisions (song_id, revision, created_at, author, action, group_id, song, release_date, text, link)
+SELECT id, 1, COALESCE(updated_at, created_at, now()), 'system', 'import', group_id, song, release_date, text, link
+FROM songs
+WHERE NOT EXISTS (SELECT 1 FROM song_revisions WHERE song_revisions.song_id = songs.id);
@@ -0,0 +1,2 @@
+-- Версия песни для оптимистичной блокировки (ETag / If-Match).
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
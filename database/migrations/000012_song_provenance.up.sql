@@ -0,0 +1,2 @@
+-- Происхождение полей песни: источник и время получения release_date, text и link.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS provenance JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_songs_song_lower_trgm;
+DROP INDEX IF EXISTS idx_groups_name_lower_trgm;
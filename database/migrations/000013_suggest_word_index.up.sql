@@ -0,0 +1,4 @@
+-- Подсказки по слову внутри названия (LIKE '% слово%'): триграммные индексы по lower(...),
+-- чтобы совпадения не по началу названия не требовали полного просмотра таблиц.
+CREATE INDEX IF NOT EXISTS idx_groups_name_lower_trgm ON groups USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_songs_song_lower_trgm ON songs USING GIN (lower(song) gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_groups_normalized_name;
+CREATE INDEX IF NOT EXISTS idx_groups_normalized_name ON groups (normalized_name);
@@ -0,0 +1,49 @@
+-- Одна неудалённая группа на нормализованное название: параллельные запросы с разным
+-- написанием группы ("The  Beatles", "the beatles") больше не создают дубликатов.
+UPDATE groups SET normalized_name = music_name_key(name) WHERE normalized_name IS NULL;
+
+-- Существующие дубликаты сливаем в группу с наименьшим ID, как POST /admin/groups/merge.
+-- Песня, название которой в этой группе уже занято, переносится вместе с остальными,
+-- но попадает в корзину.
+WITH duplicates AS (
+    SELECT id, min(id) OVER (PARTITION BY normalized_name) AS keeper_id
+    FROM groups
+    WHERE deleted_at IS NULL
+), ranked AS (
+    SELECT songs.id,
+           row_number() OVER (
+               PARTITION BY d.keeper_id, songs.normalized_song
+               ORDER BY songs.group_id = d.keeper_id DESC, songs.id
+           ) AS n
+    FROM songs
+    JOIN duplicates d ON d.id = songs.group_id
+    WHERE songs.deleted_at IS NULL
+)
+UPDATE songs
+SET deleted_at = now()
+FROM ranked
+WHERE songs.id = ranked.id AND ranked.n > 1;
+
+WITH duplicates AS (
+    SELECT id, min(id) OVER (PARTITION BY normalized_name) AS keeper_id
+    FROM groups
+    WHERE deleted_at IS NULL
+)
+UPDATE songs
+SET group_id = duplicates.keeper_id
+FROM duplicates
+WHERE songs.group_id = duplicates.id AND duplicates.id <> duplicates.keeper_id;
+
+WITH duplicates AS (
+    SELECT id, min(id) OVER (PARTITION BY normalized_name) AS keeper_id
+    FROM groups
+    WHERE deleted_at IS NULL
+)
+UPDATE groups
+SET deleted_at = now()
+FROM duplicates
+WHERE groups.id = duplicates.id AND duplicates.id <> duplicates.keeper_id;
+
+DROP INDEX IF EXISTS idx_groups_normalized_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_normalized_name ON groups (normalized_name)
+    WHERE deleted_at IS NULL;
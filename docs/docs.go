@@ -15,89 +15,92 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/info": {
+        "/admin/cache": {
             "get": {
-                "description": "Retrieve detailed information about a song, add to database if not present",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Size, TTLs and hit counters of the cache of external API responses",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get song details",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Group",
-                        "name": "group",
-                        "in": "query",
-                        "required": true
+                "summary": "Enrichment cache stats",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/enrichment.CacheStats"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
                     },
+                    "404": {
+                        "description": "cache is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "Song",
-                        "name": "song",
-                        "in": "query",
-                        "required": true
+                        "AdminToken": []
                     }
                 ],
+                "description": "Remove all cached external API responses, including the shared cache backend if one is configured",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Flush the enrichment cache",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SongDetail"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "bad request",
+                    "401": {
+                        "description": "missing or invalid admin token",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "cache is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "500": {
                         "description": "internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs": {
+        "/admin/cache/entries": {
             "get": {
-                "description": "Retrieve all songs with optional filtering and pagination",
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Unexpired responses of the local cache, most recently used first. Entries with found = false are cached not-found answers. Expired entries kept for serving while the external API is unavailable are not listed and only counted in GET /admin/cache.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get all songs",
+                "summary": "List enrichment cache entries",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Group",
-                        "name": "group",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Song",
-                        "name": "song",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Release Date",
-                        "name": "release_date",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Text",
-                        "name": "text",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Link",
-                        "name": "link",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -117,87 +120,151 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Song"
-                            }
+                            "$ref": "#/definitions/controllers.CacheEntriesResponse"
                         }
                     },
-                    "500": {
-                        "description": "internal server error",
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "cache is disabled",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a new song",
-                "consumes": [
-                    "application/json"
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Remove the cached external API responses for a song, so the next /info request asks the API again",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Create a new song",
+                "summary": "Invalidate a cached song",
                 "parameters": [
                     {
-                        "description": "New song data",
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song",
                         "name": "song",
-                        "in": "body",
-                        "required": true,
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.NewSongRequest"
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "missing parameters",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "cache is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
+                }
+            }
+        },
+        "/admin/groups/duplicates": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Find groups whose names only differ in case, whitespace, Unicode form or a leading \"The\"",
+                "produces": [
+                    "application/json"
                 ],
+                "summary": "List duplicate groups",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/controllers.DuplicateGroupsResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "invalid input",
+                    "401": {
+                        "description": "missing or invalid admin token",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "500": {
                         "description": "internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}": {
-            "put": {
-                "description": "Update an existing song by its ID",
+        "/admin/groups/merge": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Move all songs of the source groups into the target group, delete the source groups and merge songs that became duplicates. Without source_ids all groups with the same normalized name as the target are merged.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Update a song",
+                "summary": "Merge groups",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Song ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated song data",
-                        "name": "song",
+                        "description": "Target group and groups to merge into it",
+                        "name": "merge",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/controllers.MergeGroupsRequest"
                         }
                     }
                 ],
@@ -205,33 +272,52 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/repository.MergeReport"
                         }
                     },
                     "400": {
                         "description": "invalid input",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "404": {
                         "description": "not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a song by its ID",
+            }
+        },
+        "/admin/groups/{id}/merge-songs": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Merge songs of the group whose names are equal after normalization into the oldest one. Empty fields are filled from the duplicates, the longest text is kept and lookups are summed.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Delete a song",
+                "summary": "Merge duplicate songs of a group",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Song ID",
+                        "description": "Group ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -241,89 +327,92 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/repository.MergeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid group id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "404": {
                         "description": "not found",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "500": {
                         "description": "internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update one or multiple fields of an existing song",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/admin/trash/purge": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Permanently delete songs and groups that were deleted longer ago than the retention period (TRASH_RETENTION, 30 days by default). A group is only purged once none of its songs remain.",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Partially update a song",
+                "summary": "Purge trash",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Song ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Updated fields",
-                        "name": "song",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                        "type": "string",
+                        "description": "Retention period overriding TRASH_RETENTION, e.g. 720h",
+                        "name": "older_than",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Song"
+                            "$ref": "#/definitions/repository.PurgeReport"
                         }
                     },
                     "400": {
-                        "description": "invalid input",
+                        "description": "invalid query parameter",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
-                    "404": {
-                        "description": "not found",
+                    "401": {
+                        "description": "missing or invalid admin token",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
             }
         },
-        "/songs/{id}/verses": {
+        "/groups": {
             "get": {
-                "description": "Retrieve the text of a song by its ID with pagination by verses",
+                "description": "Retrieve groups ordered by name with the number of songs in each",
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Get a song by ID with pagination",
+                "summary": "List groups",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Song ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -333,8 +422,8 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 1,
-                        "description": "Verses per page",
+                        "default": 10,
+                        "description": "Results per page",
                         "name": "limit",
                         "in": "query"
                     }
@@ -343,50 +432,1813 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/controllers.GroupListResponse"
                         }
                     },
-                    "404": {
-                        "description": "not found",
+                    "400": {
+                        "description": "invalid query parameter",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     },
                     "500": {
                         "description": "internal server error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/controllers.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Group": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
+        },
+        "/groups/{id}": {
+            "get": {
+                "description": "Retrieve a group by its ID with the number of its songs",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.GroupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid group id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move a group to the trash. A group that still has songs is only deleted with cascade=true, which moves its songs to the trash too.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Also delete the songs of the group",
+                        "name": "cascade",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "invalid group id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "group still has songs",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Change the name of a group; the name must not be used by another group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Rename a group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New group name",
+                        "name": "group",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.GroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.GroupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "group name is already taken",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}/songs": {
+            "get": {
+                "description": "Retrieve the songs of a group; accepts the same filtering, sorting and pagination parameters as GET /songs",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List songs of a group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "song",
+                            "release_date",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort direction",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Results per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor for keyset pagination",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/info": {
+            "get": {
+                "description": "Retrieve detailed information about a song. A song missing from the library is looked up in the configured enrichment sources (ENRICHMENT_PROVIDERS) in order and added to the library.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get song details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "Minimum similarity for the did_you_mean suggestion",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongDetail"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "bad request",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found, did_you_mean suggests a similar one",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "group or song name is invalid",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "enrichment sources failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "external API is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "song lookup timed out",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Search song titles and lyrics with Russian and English stemming. Results are ordered by relevance and include highlighted snippets.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Full-text search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query (web search syntax: words, \\",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Results per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/search/fuzzy": {
+            "get": {
+                "description": "Find groups and songs whose names are similar to the query (trigram similarity)",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Typo-tolerant name search",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group or song name, possibly misspelled",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "group",
+                            "song"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to groups or songs",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.3,
+                        "description": "Minimum similarity in (0, 1]",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of results",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.SimilarMatch"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs": {
+            "get": {
+                "description": "Retrieve all songs with optional filtering, sorting and pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get all songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "group_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Song",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Text (substring)",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "contains",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "default": "contains",
+                        "description": "Matching for group, song and link",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact release date (YYYY-MM-DD)",
+                        "name": "release_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Released on or after (YYYY-MM-DD)",
+                        "name": "released_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Released on or before (YYYY-MM-DD)",
+                        "name": "released_to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "song",
+                            "group",
+                            "release_date",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "default": "asc",
+                        "description": "Sort direction",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Results per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor; when present (even empty) keyset pagination is used instead of page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Include total and total_pages (set to false to skip the COUNT query)",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Links to the next and previous pages in cursor mode (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new song; the group is created if it does not exist yet. release_date accepts YYYY-MM-DD, DD.MM.YYYY or RFC 3339.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Create a new song",
+                "parameters": [
+                    {
+                        "description": "New song data",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.NewSongRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author of the change, stored in the song revision",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "song already exists in group",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}": {
+            "get": {
+                "description": "Retrieve a song by its ID. The ETag header carries the song version: send it back in If-Match to update or delete the song only if nobody changed it in between.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "invalid song id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace all editable fields of a song by its ID; optional fields missing from the body are cleared. release_date accepts YYYY-MM-DD, DD.MM.YYYY or RFC 3339.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Update a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New song data",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReplaceSongRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author of the change, stored in the song revision",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the song version being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "song already exists in group",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move a song to the trash; it can be restored with POST /songs/{id}/restore until the trash is purged",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the song version being deleted",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Update one or multiple fields of an existing song. The body is a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json) applied to the song as returned by GET. Only group_id, song, release_date (YYYY-MM-DD, DD.MM.YYYY or RFC 3339), text and link can be changed; null clears release_date, text and link.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Partially update a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch object, or an array of utils.PatchOperation for application/json-patch+json",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchSongRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author of the change, stored in the song revision",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the song version being edited",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "song already exists in group or json patch cannot be applied",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "precondition failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported content type",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation failed",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/restore": {
+            "post": {
+                "description": "Restore a song from the trash; its group is restored too if it was deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Restore a song",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid song id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found in trash",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "song already exists in group",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions": {
+            "get": {
+                "description": "Retrieve every stored revision of a song, newest first. Each revision holds the author, the action, the changed fields with old and new values and the song state after the change.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List song revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RevisionListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid song id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/diff": {
+            "get": {
+                "description": "Compare two revisions of a song: changed metadata fields and a line-level diff of the lyrics. By default the latest revision is compared with the previous one.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Diff song revisions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Older revision (defaults to to-1; for the first revision the diff is against an empty song and from is 0)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Newer revision (defaults to the latest)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.RevisionDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/revisions/{rev}/restore": {
+            "post": {
+                "description": "Reset the song fields to their state in the given revision. The rollback itself is stored as a new revision.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Restore a song revision",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Revision number",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Author of the change",
+                        "name": "X-Author",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SongResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid song id",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "song already exists in group",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/songs/{id}/verses": {
+            "get": {
+                "description": "Retrieve the text of a song by its ID with pagination by verses",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a song by ID with pagination",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Song ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Verses per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggest": {
+            "get": {
+                "description": "Suggest group and song names starting with the typed prefix, ranked by prefix match and popularity",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Autocomplete names",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Typed prefix",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "group",
+                            "song"
+                        ],
+                        "type": "string",
+                        "description": "Restrict to groups or songs",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximum number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.Suggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "Retrieve deleted songs and groups that can still be restored, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "List trash",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Results per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TrashListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameter",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.CacheEntriesResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/enrichment.CacheEntry"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.DuplicateGroupsResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.GroupResponse"
+                    }
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.GroupListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.GroupResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.GroupResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "song_count": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.MergeGroupsRequest": {
+            "type": "object",
+            "required": [
+                "target_id"
+            ],
+            "properties": {
+                "source_ids": {
+                    "description": "SourceIDs — группы, которые сливаются в целевую. Если список пуст,\nсливаются все группы с тем же нормализованным названием.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "target_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code — машиночитаемый код ошибки.",
+                    "type": "string",
+                    "example": "not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "song with ID 5: not found"
+                },
+                "did_you_mean": {
+                    "description": "DidYouMean — похожая песня из библиотеки для 404 от GET /info.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/repository.SongSuggestion"
+                        }
+                    ]
+                },
+                "errors": {
+                    "description": "Errors — ошибки отдельных полей для code = validation_failed.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/songs/5"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/not_found"
+                }
+            }
+        },
+        "controllers.RevisionDiffResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "description": "Changes — поля, отличающиеся в ревизиях, кроме текста.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.FieldChanges"
+                        }
+                    ]
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "description": "Lines — построчное сравнение текста песни.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.DiffLine"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.RevisionListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.RevisionResponse"
+                    }
+                },
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.RevisionResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "import",
+                        "create",
+                        "update",
+                        "restore",
+                        "merge"
+                    ]
+                },
+                "author": {
+                    "type": "string",
+                    "example": "anonymous"
+                },
+                "changes": {
+                    "$ref": "#/definitions/models.FieldChanges"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "restored_from": {
+                    "description": "RestoredFrom — номер ревизии, к которой откатили песню (для action = restore).",
+                    "type": "integer"
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SearchHit": {
+            "type": "object",
+            "properties": {
+                "highlight": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "song": {
+                    "$ref": "#/definitions/controllers.SongResponse"
+                }
+            }
+        },
+        "controllers.SearchResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.SearchHit"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.SongListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/controllers.SongResponse"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
                     "type": "string"
                 },
-                "id": {
+                "page": {
                     "type": "integer"
                 },
-                "name": {
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "controllers.SongResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string"
+                },
+                "provenance": {
+                    "description": "Provenance — источник и время получения release_date, text и link.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Provenance"
+                        }
+                    ]
+                },
+                "release_date": {
+                    "description": "ReleaseDate — дата выпуска в формате YYYY-MM-DD, null если неизвестна.",
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
                 },
-                "songs": {
+                "version": {
+                    "description": "Version растёт при каждом изменении песни, из неё же строится ETag.",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "controllers.TrashListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Song"
+                        "$ref": "#/definitions/repository.TrashItem"
                     }
                 },
-                "updated_at": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "enrichment.CacheEntry": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "$ref": "#/definitions/models.SongDetail"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "found": {
+                    "type": "boolean"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "enrichment.CacheStats": {
+            "type": "object",
+            "properties": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "evictions": {
+                    "type": "integer"
+                },
+                "expired": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "misses": {
+                    "type": "integer"
+                },
+                "negative_hits": {
+                    "type": "integer"
+                },
+                "not_found_ttl": {
+                    "type": "string",
+                    "example": "5m0s"
+                },
+                "shared": {
+                    "type": "boolean"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "stale_hits": {
+                    "type": "integer"
+                },
+                "ttl": {
+                    "type": "string",
+                    "example": "1h0m0s"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "new": {},
+                "old": {}
+            }
+        },
+        "models.FieldChanges": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/models.FieldChange"
+            }
+        },
+        "models.FieldSource": {
+            "type": "object",
+            "properties": {
+                "fetched_at": {
                     "type": "string"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "catalog"
+                }
+            }
+        },
+        "models.GroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
                 }
             }
         },
@@ -398,24 +2250,156 @@ const docTemplate = `{
             ],
             "properties": {
                 "group": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Muse"
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "release_date": {
+                    "description": "ReleaseDate — дата выпуска: YYYY-MM-DD, DD.MM.YYYY или RFC 3339.",
+                    "type": "string",
+                    "example": "2006-07-16"
                 },
                 "song": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 65536
                 }
             }
         },
-        "models.Song": {
+        "models.PatchSongRequest": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 65536
+                }
+            }
+        },
+        "models.Provenance": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/models.FieldSource"
+            }
+        },
+        "models.ReplaceSongRequest": {
+            "type": "object",
+            "required": [
+                "group_id",
+                "song"
+            ],
+            "properties": {
+                "group_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "link": {
+                    "type": "string",
+                    "maxLength": 1000,
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-07-16"
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 65536
+                }
+            }
+        },
+        "models.SongDetail": {
+            "type": "object",
+            "properties": {
+                "link": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "provenance": {
+                    "description": "Provenance — происхождение полей в ответе GET /info; внешний API его не присылает.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Provenance"
+                        }
+                    ]
+                },
+                "release_date": {
+                    "description": "ReleaseDate — дата в формате YYYY-MM-DD или пустая строка, если она неизвестна.",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.MergeReport": {
+            "type": "object",
+            "properties": {
+                "groups_merged": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "songs_merged": {
+                    "type": "integer"
+                },
+                "songs_moved": {
+                    "type": "integer"
+                },
+                "target_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.PurgeReport": {
+            "type": "object",
+            "properties": {
+                "before": {
                     "type": "string"
                 },
+                "groups_purged": {
+                    "type": "integer"
+                },
+                "songs_purged": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.SimilarMatch": {
+            "type": "object",
+            "properties": {
                 "group": {
-                    "$ref": "#/definitions/models.Group"
+                    "type": "string"
                 },
                 "group_id": {
                     "type": "integer"
@@ -423,30 +2407,97 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
-                "link": {
+                "name": {
                     "type": "string"
                 },
-                "release_date": {
+                "similarity": {
+                    "type": "number"
+                },
+                "type": {
+                    "$ref": "#/definitions/repository.SimilarityKind"
+                }
+            }
+        },
+        "repository.SimilarityKind": {
+            "type": "string",
+            "enum": [
+                "",
+                "group",
+                "song"
+            ],
+            "x-enum-varnames": [
+                "SimilarAll",
+                "SimilarGroups",
+                "SimilarSongs"
+            ]
+        },
+        "repository.SongSuggestion": {
+            "type": "object",
+            "properties": {
+                "group": {
                     "type": "string"
                 },
+                "group_id": {
+                    "type": "integer"
+                },
+                "similarity": {
+                    "type": "number"
+                },
                 "song": {
                     "type": "string"
                 },
-                "text": {
+                "song_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repository.Suggestion": {
+            "type": "object",
+            "properties": {
+                "group": {
                     "type": "string"
                 },
-                "updated_at": {
+                "id": {
+                    "type": "integer"
+                },
+                "popularity": {
+                    "type": "integer"
+                },
+                "text": {
                     "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/repository.SimilarityKind"
                 }
             }
         },
-        "models.SongDetail": {
+        "repository.TrashItem": {
             "type": "object",
             "properties": {
-                "link": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "release_date": {
+                "group": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "utils.DiffLine": {
+            "type": "object",
+            "properties": {
+                "op": {
                     "type": "string"
                 },
                 "text": {
@@ -454,6 +2505,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin token as \"Bearer \u003ctoken\u003e\"; /admin endpoints are disabled until ADMIN_TOKEN is set.",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,362 @@
+package enrichment
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"music-library/httpclient"
+	"music-library/models"
+	"music-library/utils"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Настройки кэша по умолчанию.
+const (
+	DefaultCacheSize        = 1000
+	DefaultCacheTTL         = time.Hour
+	DefaultCacheNotFoundTTL = 5 * time.Minute
+)
+
+// CacheEntry — закэшированный ответ источника: данные песни или то, что источник её не знает.
+type CacheEntry struct {
+	Key       string            `json:"key"`
+	Found     bool              `json:"found"`
+	Detail    models.SongDetail `json:"detail"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// CacheBackend — хранилище записей кэша. LRU хранит их в памяти процесса; общее хранилище
+// (например, Redis) позволяет нескольким экземплярам сервиса делить один кэш.
+// Просроченные записи хранилище может не удалять: Cache проверяет ExpiresAt сам.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	Set(ctx context.Context, entry CacheEntry) error
+	Delete(ctx context.Context, key string) error
+	Flush(ctx context.Context) error
+}
+
+// LRU — кэш в памяти процесса на capacity записей; при переполнении вытесняется запись,
+// к которой дольше всего не обращались.
+type LRU struct {
+	capacity int
+
+	mu        sync.Mutex
+	order     *list.List
+	items     map[string]*list.Element
+	evictions int64
+}
+
+func NewLRU(capacity int) *LRU {
+	return &LRU{capacity: capacity, order: list.New(), items: map[string]*list.Element{}}
+}
+
+func (l *LRU) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.items[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(CacheEntry), true, nil
+}
+
+func (l *LRU) Set(ctx context.Context, entry CacheEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[entry.Key]; ok {
+		element.Value = entry
+		l.order.MoveToFront(element)
+		return nil
+	}
+	l.items[entry.Key] = l.order.PushFront(entry)
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(CacheEntry).Key)
+		l.evictions++
+	}
+	return nil
+}
+
+func (l *LRU) Delete(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[key]; ok {
+		l.order.Remove(element)
+		delete(l.items, key)
+	}
+	return nil
+}
+
+func (l *LRU) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.items = map[string]*list.Element{}
+	return nil
+}
+
+// Entries пропускает offset непросроченных на момент now записей, начиная с последних
+// использованных, и возвращает до limit следующих вместе с числом всех непросроченных.
+func (l *LRU) Entries(now time.Time, offset, limit int) ([]CacheEntry, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := []CacheEntry{}
+	live := 0
+	for element := l.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(CacheEntry)
+		if !now.Before(entry.ExpiresAt) {
+			continue
+		}
+		if live >= offset && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+		live++
+	}
+	return entries, live
+}
+
+// counts возвращает число непросроченных и просроченных записей и вытеснений.
+func (l *LRU) counts(now time.Time) (int, int, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	live := 0
+	for element := l.order.Front(); element != nil; element = element.Next() {
+		if now.Before(element.Value.(CacheEntry).ExpiresAt) {
+			live++
+		}
+	}
+	return live, l.order.Len() - live, l.evictions
+}
+
+// CacheOptions — размер локального кэша и время жизни найденных и ненайденных песен.
+type CacheOptions struct {
+	Size        int
+	TTL         time.Duration
+	NotFoundTTL time.Duration
+}
+
+// CacheStats — состояние кэша для GET /admin/cache. Size — непросроченные записи,
+// Expired — просроченные, которые хранятся, чтобы отдавать их, пока circuit breaker разомкнут.
+type CacheStats struct {
+	Size         int    `json:"size"`
+	Expired      int    `json:"expired"`
+	Capacity     int    `json:"capacity"`
+	Shared       bool   `json:"shared"`
+	TTL          string `json:"ttl" example:"1h0m0s"`
+	NotFoundTTL  string `json:"not_found_ttl" example:"5m0s"`
+	Hits         int64  `json:"hits"`
+	NegativeHits int64  `json:"negative_hits"`
+	StaleHits    int64  `json:"stale_hits"`
+	Misses       int64  `json:"misses"`
+	Evictions    int64  `json:"evictions"`
+}
+
+// Cache запоминает ответы источников enrichment: найденные песни на TTL, ненайденные — на NotFoundTTL.
+// Записи ищутся сначала в локальном LRU, затем в общем хранилище, если оно задано.
+// Ошибки источников не кэшируются. Пока circuit breaker источника разомкнут, отдаётся
+// последняя запись о песне, даже просроченная; срок её жизни при этом не продлевается.
+type Cache struct {
+	opts   CacheOptions
+	local  *LRU
+	shared CacheBackend
+
+	mu        sync.Mutex
+	providers map[string]bool
+
+	hits, negativeHits, staleHits, misses atomic.Int64
+}
+
+// NewCache создаёт кэш; shared может быть nil.
+func NewCache(opts CacheOptions, shared CacheBackend) *Cache {
+	if opts.Size <= 0 {
+		opts.Size = DefaultCacheSize
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+	if opts.NotFoundTTL <= 0 {
+		opts.NotFoundTTL = DefaultCacheNotFoundTTL
+	}
+	return &Cache{opts: opts, local: NewLRU(opts.Size), shared: shared, providers: map[string]bool{}}
+}
+
+// Wrap возвращает источник, ответы которого проходят через кэш.
+func (c *Cache) Wrap(provider Provider) Provider {
+	c.mu.Lock()
+	c.providers[provider.Name()] = true
+	c.mu.Unlock()
+	return &cachedProvider{provider: provider, cache: c}
+}
+
+// Stats возвращает размер кэша и счётчики обращений.
+func (c *Cache) Stats() CacheStats {
+	size, expired, evictions := c.local.counts(time.Now())
+	return CacheStats{
+		Size:         size,
+		Expired:      expired,
+		Capacity:     c.opts.Size,
+		Shared:       c.shared != nil,
+		TTL:          c.opts.TTL.String(),
+		NotFoundTTL:  c.opts.NotFoundTTL.String(),
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		StaleHits:    c.staleHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    evictions,
+	}
+}
+
+// Entries возвращает страницу непросроченных записей локального кэша, начиная с последних
+// использованных, и число всех непросроченных записей.
+func (c *Cache) Entries(offset, limit int) ([]CacheEntry, int) {
+	return c.local.Entries(time.Now(), offset, limit)
+}
+
+// Invalidate удаляет записи о песне для всех закэшированных источников.
+func (c *Cache) Invalidate(ctx context.Context, group, song string) error {
+	c.mu.Lock()
+	var keys []string
+	for provider := range c.providers {
+		keys = append(keys, cacheKey(provider, group, song))
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, key := range keys {
+		errs = append(errs, c.local.Delete(ctx, key))
+		if c.shared != nil {
+			errs = append(errs, c.shared.Delete(ctx, key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush очищает локальный кэш и общее хранилище.
+func (c *Cache) Flush(ctx context.Context) error {
+	err := c.local.Flush(ctx)
+	if c.shared != nil {
+		err = errors.Join(err, c.shared.Flush(ctx))
+	}
+	return err
+}
+
+// get ищет непросроченную запись. Ошибка общего хранилища считается промахом.
+func (c *Cache) get(ctx context.Context, key string) (CacheEntry, bool) {
+	now := time.Now()
+	if entry, ok, _ := c.local.Get(ctx, key); ok && now.Before(entry.ExpiresAt) {
+		return entry, true
+	}
+	if c.shared == nil {
+		return CacheEntry{}, false
+	}
+	entry, ok, err := c.shared.Get(ctx, key)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read shared enrichment cache")
+		return CacheEntry{}, false
+	}
+	if !ok || !now.Before(entry.ExpiresAt) {
+		return CacheEntry{}, false
+	}
+	_ = c.local.Set(ctx, entry)
+	return entry, true
+}
+
+// stale ищет запись независимо от срока жизни, не перенося её в локальный кэш.
+func (c *Cache) stale(ctx context.Context, key string) (CacheEntry, bool) {
+	if entry, ok, _ := c.local.Get(ctx, key); ok {
+		return entry, true
+	}
+	if c.shared == nil {
+		return CacheEntry{}, false
+	}
+	entry, ok, err := c.shared.Get(ctx, key)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read shared enrichment cache")
+		return CacheEntry{}, false
+	}
+	return entry, ok
+}
+
+func (c *Cache) set(ctx context.Context, entry CacheEntry) {
+	ttl := c.opts.NotFoundTTL
+	if entry.Found {
+		ttl = c.opts.TTL
+	}
+	entry.ExpiresAt = time.Now().Add(ttl)
+	_ = c.local.Set(ctx, entry)
+	if c.shared != nil {
+		if err := c.shared.Set(ctx, entry); err != nil {
+			log.WithError(err).Warn("Failed to write shared enrichment cache")
+		}
+	}
+}
+
+func cacheKey(provider, group, song string) string {
+	return provider + ":" + utils.NameKey(group) + "\x00" + utils.NameKey(song)
+}
+
+// cachedProvider отвечает из кэша и запоминает ответы источника.
+type cachedProvider struct {
+	provider Provider
+	cache    *Cache
+}
+
+func (p *cachedProvider) Name() string {
+	return p.provider.Name()
+}
+
+func (p *cachedProvider) Lookup(ctx context.Context, group, song string) (*models.Song, error) {
+	key := cacheKey(p.provider.Name(), group, song)
+	if entry, ok := p.cache.get(ctx, key); ok {
+		if !entry.Found {
+			p.cache.negativeHits.Add(1)
+		} else {
+			p.cache.hits.Add(1)
+		}
+		return entrySong(song, entry)
+	}
+	p.cache.misses.Add(1)
+
+	found, err := p.provider.Lookup(ctx, group, song)
+	if errors.Is(err, httpclient.ErrCircuitOpen) {
+		if entry, ok := p.cache.stale(ctx, key); ok {
+			log.WithError(err).Warnf("Serving stale %s entry for %q - %q", p.provider.Name(), group, song)
+			p.cache.staleHits.Add(1)
+			return entrySong(song, entry)
+		}
+	}
+	if errors.Is(err, ErrNotFound) {
+		p.cache.set(ctx, CacheEntry{Key: key})
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Сохранённые в библиотеке песни не кэшируются: их источник — сама библиотека
+	if found.ID == 0 {
+		p.cache.set(ctx, CacheEntry{Key: key, Found: true, Detail: models.SongDetail{
+			ReleaseDate: models.SongField(found, "release_date"),
+			Text:        found.Text,
+			Link:        found.Link,
+			Provenance:  found.Provenance,
+		}})
+	}
+	return found, nil
+}
+
+// entrySong восстанавливает ответ источника из записи кэша вместе с происхождением полей.
+func entrySong(song string, entry CacheEntry) (*models.Song, error) {
+	if !entry.Found {
+		return nil, ErrNotFound
+	}
+	found, err := newSong(song, entry.Detail)
+	if err != nil {
+		return nil, err
+	}
+	found.Provenance = entry.Detail.Provenance
+	return found, nil
+}
@@ -0,0 +1,235 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-library/httpclient"
+	"music-library/models"
+	"testing"
+	"time"
+)
+
+// fakeProvider отвечает заданной песней или ошибкой и считает вызовы.
+type fakeProvider struct {
+	song  *models.Song
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Lookup(ctx context.Context, group, song string) (*models.Song, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	found := *p.song
+	return &found, nil
+}
+
+var errBoom = errors.New("boom")
+
+func foundSong() *models.Song {
+	return &models.Song{
+		Song:        "Supermassive Black Hole",
+		ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+		Text:        "Ooh baby, don't you know I suffer?",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+}
+
+func TestCachedProviderLookup(t *testing.T) {
+	opts := CacheOptions{Size: 10, TTL: time.Hour, NotFoundTTL: time.Hour}
+	tests := []struct {
+		name      string
+		provider  *fakeProvider
+		wantErr   error
+		wantCalls int
+		wantStats CacheStats
+	}{
+		{
+			name:      "found song is cached",
+			provider:  &fakeProvider{song: foundSong()},
+			wantCalls: 1,
+			wantStats: CacheStats{Size: 1, Hits: 2, Misses: 1},
+		},
+		{
+			name:      "not found is cached",
+			provider:  &fakeProvider{err: ErrNotFound},
+			wantErr:   ErrNotFound,
+			wantCalls: 1,
+			wantStats: CacheStats{Size: 1, NegativeHits: 2, Misses: 1},
+		},
+		{
+			name:      "errors are not cached",
+			provider:  &fakeProvider{err: errBoom},
+			wantErr:   errBoom,
+			wantCalls: 3,
+			wantStats: CacheStats{Misses: 3},
+		},
+		{
+			name:      "library songs are not cached",
+			provider:  &fakeProvider{song: &models.Song{ID: 1, Song: "Uprising"}},
+			wantCalls: 3,
+			wantStats: CacheStats{Misses: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache(opts, nil)
+			provider := cache.Wrap(tt.provider)
+			for i := 0; i < 3; i++ {
+				found, err := provider.Lookup(context.Background(), "Muse", "Supermassive Black Hole")
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("lookup %d: error = %v, want %v", i, err, tt.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("lookup %d: unexpected error: %v", i, err)
+				}
+				if found.Text != tt.provider.song.Text || !found.ReleaseDate.Equal(tt.provider.song.ReleaseDate) {
+					t.Errorf("lookup %d: song = %+v, want %+v", i, found, tt.provider.song)
+				}
+			}
+			if tt.provider.calls != tt.wantCalls {
+				t.Errorf("provider calls = %d, want %d", tt.provider.calls, tt.wantCalls)
+			}
+			stats := cache.Stats()
+			got := CacheStats{Size: stats.Size, Hits: stats.Hits, NegativeHits: stats.NegativeHits, Misses: stats.Misses}
+			if got != tt.wantStats {
+				t.Errorf("stats = %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider *fakeProvider
+		opts     CacheOptions
+	}{
+		{
+			name:     "found song expires after TTL",
+			provider: &fakeProvider{song: foundSong()},
+			opts:     CacheOptions{TTL: 20 * time.Millisecond, NotFoundTTL: time.Hour},
+		},
+		{
+			name:     "not found expires after NotFoundTTL",
+			provider: &fakeProvider{err: ErrNotFound},
+			opts:     CacheOptions{TTL: time.Hour, NotFoundTTL: 20 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache(tt.opts, nil)
+			provider := cache.Wrap(tt.provider)
+			lookup := func() {
+				_, _ = provider.Lookup(context.Background(), "Muse", "Uprising")
+			}
+
+			lookup()
+			lookup()
+			if tt.provider.calls != 1 {
+				t.Fatalf("provider calls = %d, want 1 before expiry", tt.provider.calls)
+			}
+			time.Sleep(30 * time.Millisecond)
+			if stats := cache.Stats(); stats.Size != 0 || stats.Expired != 1 {
+				t.Errorf("stats after expiry = %+v, want one expired entry", stats)
+			}
+			if entries, total := cache.Entries(0, 10); len(entries) != 0 || total != 0 {
+				t.Errorf("entries after expiry = %v (%d), want none", entries, total)
+			}
+			lookup()
+			if tt.provider.calls != 2 {
+				t.Errorf("provider calls = %d, want 2 after expiry", tt.provider.calls)
+			}
+		})
+	}
+}
+
+func TestCacheServesStaleWhileCircuitOpen(t *testing.T) {
+	cache := NewCache(CacheOptions{TTL: 20 * time.Millisecond}, nil)
+	fake := &fakeProvider{song: foundSong()}
+	provider := cache.Wrap(fake)
+	if _, err := provider.Lookup(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	fake.err = fmt.Errorf("fake: %w", httpclient.ErrCircuitOpen)
+	for i := 0; i < 2; i++ {
+		found, err := provider.Lookup(context.Background(), "Muse", "Uprising")
+		if err != nil {
+			t.Fatalf("lookup %d: unexpected error: %v", i, err)
+		}
+		if found.Link != fake.song.Link {
+			t.Errorf("lookup %d: link = %q, want %q", i, found.Link, fake.song.Link)
+		}
+	}
+	if fake.calls != 3 {
+		t.Errorf("provider calls = %d, want 3: a stale entry must not be treated as fresh", fake.calls)
+	}
+	if stats := cache.Stats(); stats.StaleHits != 2 || stats.Size != 0 || stats.Expired != 1 {
+		t.Errorf("stats = %+v, want 2 stale hits on one expired entry", stats)
+	}
+
+	// Без записи в кэше ошибка источника возвращается как есть
+	if _, err := provider.Lookup(context.Background(), "Muse", "Hysteria"); !errors.Is(err, httpclient.ErrCircuitOpen) {
+		t.Errorf("error = %v, want %v", err, httpclient.ErrCircuitOpen)
+	}
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	cache := NewCache(CacheOptions{}, nil)
+	fake := &fakeProvider{song: foundSong()}
+	provider := cache.Wrap(fake)
+	lookup := func() {
+		if _, err := provider.Lookup(context.Background(), "Muse", "Uprising"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lookup()
+	if err := cache.Invalidate(context.Background(), " muse ", "UPRISING"); err != nil {
+		t.Fatal(err)
+	}
+	lookup()
+	if fake.calls != 2 {
+		t.Errorf("provider calls = %d, want 2 after invalidation", fake.calls)
+	}
+	if err := cache.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if entries, total := cache.Entries(0, 10); len(entries) != 0 || total != 0 {
+		t.Errorf("entries after flush = %v (%d), want none", entries, total)
+	}
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	expires := time.Now().Add(time.Hour)
+	for _, key := range []string{"a", "b"} {
+		_ = lru.Set(ctx, CacheEntry{Key: key, ExpiresAt: expires})
+	}
+	_, _, _ = lru.Get(ctx, "a")
+	_ = lru.Set(ctx, CacheEntry{Key: "c", ExpiresAt: expires})
+
+	entries, total := lru.Entries(time.Now(), 0, 10)
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	if total != 2 || fmt.Sprint(keys) != "[c a]" {
+		t.Errorf("keys = %v (%d), want [c a]", keys, total)
+	}
+	if _, _, evictions := lru.counts(time.Now()); evictions != 1 {
+		t.Errorf("evictions = %d, want 1", evictions)
+	}
+}
@@ -0,0 +1,253 @@
+package enrichment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"music-library/models"
+	"music-library/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CatalogEntry — запись каталога с данными песни.
+type CatalogEntry struct {
+	Group       string `json:"group" yaml:"group"`
+	Song        string `json:"song" yaml:"song"`
+	ReleaseDate string `json:"release_date" yaml:"release_date"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+}
+
+// Catalog — локальный каталог песен из JSON- и YAML-файлов, загруженный в память.
+// Путь может указывать на файл или на каталог, в котором читаются все *.json, *.yaml и *.yml.
+// Файл содержит одну запись или массив записей. Поиск идёт по нормализованным названиям
+// группы и песни; при совпадении ключей побеждает запись из файла, прочитанного позже.
+type Catalog struct {
+	paths []string
+
+	mu      sync.RWMutex
+	entries map[string]CatalogEntry
+	// version — размеры и время изменения файлов при последней загрузке.
+	version string
+}
+
+// NewCatalog загружает каталог. Пробелы по краям путей отбрасываются, пустые пути пропускаются.
+// Отсутствующие пути считаются пустыми, о них пишется предупреждение: файлы, которые
+// появятся позже, подхватит Reload.
+func NewCatalog(paths ...string) (*Catalog, error) {
+	var cleaned []string
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			log.Warnf("Enrichment catalog path %s does not exist, it will be loaded once created", path)
+		}
+		cleaned = append(cleaned, path)
+	}
+
+	catalog := &Catalog{paths: cleaned, entries: map[string]CatalogEntry{}}
+	if _, err := catalog.Reload(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// Find возвращает данные песни как есть, без проверки, или ErrNotFound.
+func (c *Catalog) Find(group, song string) (models.SongDetail, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[catalogKey(group, song)]
+	c.mu.RUnlock()
+	if !ok {
+		return models.SongDetail{}, ErrNotFound
+	}
+	return models.SongDetail{
+		ReleaseDate: entry.ReleaseDate,
+		Text:        entry.Text,
+		Link:        entry.Link,
+	}, nil
+}
+
+// Len возвращает число песен в каталоге.
+func (c *Catalog) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Reload перечитывает файлы каталога, если они изменились с прошлой загрузки, и сообщает,
+// была ли загрузка. При ошибке чтения или разбора остаётся прежнее содержимое.
+func (c *Catalog) Reload() (bool, error) {
+	files, version, err := c.files()
+	if err != nil {
+		return false, err
+	}
+	c.mu.RLock()
+	unchanged := version == c.version
+	c.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	entries := map[string]CatalogEntry{}
+	for _, file := range files {
+		fileEntries, err := readCatalogFile(file)
+		if err != nil {
+			return false, err
+		}
+		for _, entry := range fileEntries {
+			if entry.Group == "" || entry.Song == "" {
+				return false, fmt.Errorf("catalog file %s: entry without group or song", file)
+			}
+			key := catalogKey(entry.Group, entry.Song)
+			if _, exists := entries[key]; exists {
+				log.Warnf("Catalog file %s overrides song %q of group %q", file, entry.Song, entry.Group)
+			}
+			entries[key] = entry
+		}
+	}
+
+	c.mu.Lock()
+	c.entries = entries
+	c.version = version
+	c.mu.Unlock()
+	return true, nil
+}
+
+// Watch проверяет файлы каталога каждые interval и перезагружает изменившиеся, пока не отменён ctx.
+func (c *Catalog) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// lastErr — чтобы одна и та же ошибка в файле не попадала в лог на каждой проверке
+	var lastErr string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := c.Reload()
+			if err != nil {
+				if err.Error() != lastErr {
+					log.WithError(err).Error("Failed to reload enrichment catalog, keeping the previous version")
+				}
+				lastErr = err.Error()
+				continue
+			}
+			lastErr = ""
+			if reloaded {
+				log.Infof("Enrichment catalog reloaded: %d songs", c.Len())
+			}
+		}
+	}
+}
+
+// files возвращает файлы каталога в порядке чтения и строку, которая меняется при изменении любого из них.
+func (c *Catalog) files() ([]string, string, error) {
+	var files []string
+	for _, path := range c.paths {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed to read catalog path %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var dirFiles []string
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && isCatalogFile(file) {
+				dirFiles = append(dirFiles, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed to read catalog directory %s: %w", path, err)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+
+	var version strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, "", fmt.Errorf("Failed to read catalog file %s: %w", file, err)
+		}
+		fmt.Fprintf(&version, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return files, version.String(), nil
+}
+
+func isCatalogFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// readCatalogFile читает одну запись или массив записей; YAML определяется по расширению файла.
+func readCatalogFile(path string) ([]CatalogEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read catalog file %s: %w", path, err)
+	}
+
+	unmarshal := json.Unmarshal
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		unmarshal = yaml.Unmarshal
+	}
+
+	var entries []CatalogEntry
+	if err = unmarshal(data, &entries); err != nil {
+		var entry CatalogEntry
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) || unmarshal(data, &entry) != nil {
+			return nil, fmt.Errorf("Failed to parse catalog file %s: %w", path, err)
+		}
+		entries = []CatalogEntry{entry}
+	}
+	return entries, nil
+}
+
+func catalogKey(group, song string) string {
+	return utils.NameKey(group) + "\x00" + utils.NameKey(song)
+}
+
+// CatalogProvider ищет песню в локальном каталоге.
+type CatalogProvider struct {
+	catalog *Catalog
+}
+
+func NewCatalogProvider(catalog *Catalog) *CatalogProvider {
+	return &CatalogProvider{catalog: catalog}
+}
+
+func (p *CatalogProvider) Name() string {
+	return "catalog"
+}
+
+func (p *CatalogProvider) Lookup(ctx context.Context, group, song string) (*models.Song, error) {
+	detail, err := p.catalog.Find(group, song)
+	if err != nil {
+		return nil, err
+	}
+	return newSong(song, detail)
+}
@@ -0,0 +1,51 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"music-library/httpclient"
+	"music-library/models"
+	"net/http"
+	"net/url"
+)
+
+// HTTPProvider получает песню из внешнего API: GET {baseURL}/info?group=...&song=...
+type HTTPProvider struct {
+	baseURL string
+	client  *httpclient.Client
+}
+
+func NewHTTPProvider(baseURL string, client *httpclient.Client) *HTTPProvider {
+	return &HTTPProvider{baseURL: baseURL, client: client}
+}
+
+func (p *HTTPProvider) Name() string {
+	return "api"
+}
+
+func (p *HTTPProvider) Lookup(ctx context.Context, group, song string) (*models.Song, error) {
+	apiURL := fmt.Sprintf("%s/info?group=%s&song=%s", p.baseURL, url.QueryEscape(group), url.QueryEscape(song))
+	response, err := p.client.Get(ctx, apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to call external API: %w", err)
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("external API responded with status %d", response.StatusCode)
+	}
+
+	var detail models.SongDetail
+	if err := json.Unmarshal(response.Body, &detail); err != nil {
+		return nil, fmt.Errorf("%w: external API returned invalid JSON: %v", ErrInvalidData, err)
+	}
+	found, err := newSong(song, detail)
+	if err != nil {
+		return nil, err
+	}
+	stampProvenance(found, p.Name(), response.FetchedAt)
+	return found, nil
+}
@@ -0,0 +1,139 @@
+package enrichment
+
+import (
+	"math"
+	"music-library/models"
+	"music-library/repository"
+	"time"
+)
+
+// DefaultPrecedence — порядок источников по умолчанию: ручные правки важнее локального каталога,
+// каталог важнее внешнего API.
+var DefaultPrecedence = []string{models.SourceManual, "catalog", "api"}
+
+// DefaultRefresh — через сколько значение поля снова запрашивается у того же источника.
+const DefaultRefresh = 24 * time.Hour
+
+// Policy решает, значение из какого источника сохранить и вернуть для каждого поля песни.
+// Источники, которых нет в списке, уступают всем перечисленным, но заполняют пустые поля.
+type Policy struct {
+	ranks map[string]int
+	// refresh — возраст значения, после которого источник спрашивается повторно; 0 — никогда.
+	refresh time.Duration
+}
+
+// NewPolicy создаёт политику из источников в порядке убывания приоритета.
+func NewPolicy(refresh time.Duration, sources ...string) *Policy {
+	ranks := make(map[string]int, len(sources))
+	for _, source := range sources {
+		if _, ok := ranks[source]; !ok {
+			ranks[source] = len(ranks)
+		}
+	}
+	return &Policy{ranks: ranks, refresh: refresh}
+}
+
+// Precedence возвращает источники в порядке убывания приоритета.
+func (p *Policy) Precedence() []string {
+	sources := make([]string, len(p.ranks))
+	for source, rank := range p.ranks {
+		sources[rank] = source
+	}
+	return sources
+}
+
+// rank — место источника: чем меньше, тем важнее.
+func (p *Policy) rank(source string) int {
+	if rank, ok := p.ranks[source]; ok {
+		return rank
+	}
+	return len(p.ranks)
+}
+
+// fieldRank — место текущего значения поля. Пустое значение уступает любому источнику,
+// если его не очистили вручную. Непустое значение без записи о происхождении сохранено
+// до появления provenance и считается ручным.
+func (p *Policy) fieldRank(song *models.Song, field string) int {
+	source, ok := song.Provenance[field]
+	empty := models.SongField(song, field) == ""
+	switch {
+	case ok && (!empty || source.Source == models.SourceManual):
+		return p.rank(source.Source)
+	case ok || empty:
+		return math.MaxInt
+	}
+	return p.rank(models.SourceManual)
+}
+
+// CanImprove сообщает, может ли источник заменить хотя бы одно поле песни. Источник, который
+// уже отвечал за поле, повторно спрашивается только после refresh, даже если тогда он его не заполнил.
+func (p *Policy) CanImprove(song *models.Song, source string) bool {
+	for _, field := range models.ProvenanceFields {
+		current := song.Provenance[field]
+		if current.Source == source {
+			if p.refresh > 0 && time.Since(current.FetchedAt) > p.refresh {
+				return true
+			}
+			continue
+		}
+		if p.rank(source) < p.fieldRank(song, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge переносит в dst поля src, источник которых важнее текущего, и обновляет поля,
+// полученные от того же источника. Пустое значение другого источника не затирает dst,
+// но записывает, что источник спрашивали, если у поля dst ещё нет источника.
+func (p *Policy) Merge(dst *models.Song, src models.Song) {
+	for _, field := range models.ProvenanceFields {
+		source, ok := src.Provenance[field]
+		if !ok {
+			continue
+		}
+		if current, known := dst.Provenance[field]; known && current.Source == source.Source {
+			if source.FetchedAt.After(current.FetchedAt) {
+				models.CopySongField(dst, src, field)
+				dst.Provenance = dst.Provenance.With(field, source)
+			}
+			continue
+		}
+		if models.SongField(&src, field) == "" {
+			if _, known := dst.Provenance[field]; !known && models.SongField(dst, field) == "" {
+				dst.Provenance = dst.Provenance.With(field, source)
+			}
+			continue
+		}
+		if p.rank(source.Source) < p.fieldRank(dst, field) {
+			models.CopySongField(dst, src, field)
+			dst.Provenance = dst.Provenance.With(field, source)
+		}
+	}
+}
+
+// songUpdates возвращает патч с полями merged, которые отличаются от сохранённой песни stored
+// значением или источником.
+func songUpdates(stored, merged *models.Song) repository.SongPatch {
+	var patch repository.SongPatch
+	for _, field := range models.ProvenanceFields {
+		before, after := stored.Provenance[field], merged.Provenance[field]
+		if models.SongField(stored, field) == models.SongField(merged, field) &&
+			before.Source == after.Source && before.FetchedAt.Equal(after.FetchedAt) {
+			continue
+		}
+		switch field {
+		case "release_date":
+			patch.ReleaseDate = &merged.ReleaseDate
+		case "text":
+			patch.Text = &merged.Text
+		case "link":
+			patch.Link = &merged.Link
+		}
+		if patch.Provenance == nil {
+			patch.Provenance = models.Provenance{}
+		}
+		patch.Provenance[field] = after
+	}
+	return patch
+}
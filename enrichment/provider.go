@@ -0,0 +1,204 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"music-library/models"
+	"music-library/repository"
+	"music-library/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.New()
+
+// DefaultTimeout — сколько ждать один источник, если таймаут для него не задан.
+const DefaultTimeout = 3 * time.Second
+
+var (
+	// ErrNotFound — источник не знает такой песни; цепочка переходит к следующему.
+	ErrNotFound = errors.New("song not found")
+	// ErrInvalidData — источник вернул данные, которые нельзя сохранить.
+	ErrInvalidData = errors.New("invalid song data")
+)
+
+// Provider — источник данных о песне для GET /info: внешний API, JSON-фикстуры, сама библиотека.
+type Provider interface {
+	// Name — короткое имя источника для конфигурации и логов.
+	Name() string
+	// Lookup возвращает песню по названиям группы и песни или ErrNotFound.
+	// Песня с ID = 0 ещё не сохранена в библиотеке.
+	Lookup(ctx context.Context, group, song string) (*models.Song, error)
+}
+
+// Step — источник в цепочке со своим таймаутом.
+type Step struct {
+	Provider Provider
+	Timeout  time.Duration
+}
+
+// Result — песня, собранная из ответов источников по политике приоритетов.
+type Result struct {
+	// Song — песня с полями из самых важных источников. ID = 0, если её ещё нет в библиотеке.
+	Song *models.Song
+	// Updates — изменения, которые нужно записать в уже сохранённую песню.
+	Updates repository.SongPatch
+	// Providers — источники, которые нашли песню, в порядке опроса.
+	Providers []string
+}
+
+// Chain опрашивает источники по порядку и собирает поля песни по политике приоритетов.
+type Chain struct {
+	policy *Policy
+	steps  []Step
+}
+
+func NewChain(policy *Policy, steps ...Step) *Chain {
+	return &Chain{policy: policy, steps: steps}
+}
+
+// Providers возвращает имена источников цепочки в порядке опроса.
+func (c *Chain) Providers() []string {
+	names := make([]string, 0, len(c.steps))
+	for _, step := range c.steps {
+		names = append(names, step.Provider.Name())
+	}
+	return names
+}
+
+// Policy возвращает политику приоритетов цепочки.
+func (c *Chain) Policy() *Policy {
+	return c.policy
+}
+
+// Lookup опрашивает источники по порядку. Если песня уже есть в библиотеке, спрашиваются только
+// источники, которые по политике могут заменить хотя бы одно её поле. Ошибка источника не прерывает
+// цепочку; если песню не нашёл никто, возвращается последняя ошибка, а если все ответили
+// ErrNotFound — ErrNotFound. После отмены контекста опрос прекращается: возвращается найденное
+// к этому моменту, а если не найдено ничего — ошибка контекста.
+func (c *Chain) Lookup(ctx context.Context, group, song string) (*Result, error) {
+	var merged, stored *models.Song
+	var providers []string
+	var lastErr error
+	for _, step := range c.steps {
+		name := step.Provider.Name()
+		if stored != nil && !c.policy.CanImprove(merged, name) {
+			continue
+		}
+
+		found, err := c.call(ctx, step, group, song)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				log.WithError(err).Warnf("Enrichment provider %s failed", name)
+				lastErr = err
+			}
+			if ctx.Err() != nil {
+				// Срок запроса истёк: отвечаем тем, что уже нашли, например песней из библиотеки
+				if merged != nil {
+					break
+				}
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		providers = append(providers, name)
+
+		if found.ID != 0 {
+			// Сохранённая песня становится основой, найденное раньше накладывается на неё по политике
+			stored = found
+			base := *found
+			if merged != nil {
+				c.policy.Merge(&base, *merged)
+			}
+			merged = &base
+			continue
+		}
+		if found.Provenance == nil {
+			stampProvenance(found, name, time.Now())
+		}
+		if merged == nil {
+			merged = found
+		} else {
+			c.policy.Merge(merged, *found)
+		}
+	}
+
+	if merged == nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrNotFound
+	}
+	result := &Result{Song: merged, Providers: providers}
+	if stored != nil {
+		result.Updates = songUpdates(stored, merged)
+	}
+	return result, nil
+}
+
+// stampProvenance записывает источник и время получения для всех полей песни, в том числе пустых:
+// так видно, что источник уже спрашивали.
+func stampProvenance(song *models.Song, source string, now time.Time) {
+	song.Provenance = make(models.Provenance, len(models.ProvenanceFields))
+	for _, field := range models.ProvenanceFields {
+		song.Provenance[field] = models.FieldSource{Source: source, FetchedAt: now}
+	}
+}
+
+// call вызывает источник с его таймаутом. Источник, который не следит за контекстом,
+// дорабатывает в фоне, а цепочка идёт дальше.
+func (c *Chain) call(ctx context.Context, step Step, group, song string) (*models.Song, error) {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		song *models.Song
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		found, err := step.Provider.Lookup(ctx, group, song)
+		done <- result{found, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %w", step.Provider.Name(), r.err)
+		}
+		return r.song, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: %w", step.Provider.Name(), ctx.Err())
+	}
+}
+
+// newSong собирает несохранённую песню из данных источника, проверяя их так же, как тело POST /songs.
+// Пустая дата выпуска означает, что она неизвестна.
+func newSong(song string, detail models.SongDetail) (*models.Song, error) {
+	var releaseDate time.Time
+	if detail.ReleaseDate != "" {
+		date, err := utils.ParseDate(detail.ReleaseDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: release_date %v", ErrInvalidData, err)
+		}
+		releaseDate = date
+	}
+	if err := utils.ValidateText(detail.Text); err != nil {
+		return nil, fmt.Errorf("%w: text %v", ErrInvalidData, err)
+	}
+	if err := utils.ValidateLink(detail.Link); err != nil {
+		return nil, fmt.Errorf("%w: link %v", ErrInvalidData, err)
+	}
+	return &models.Song{
+		Song:        song,
+		ReleaseDate: releaseDate,
+		Text:        detail.Text,
+		Link:        detail.Link,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"music-library/models"
+	"music-library/repository"
+)
+
+// StoreProvider ищет песню в самой библиотеке. Обычно стоит в цепочке первым,
+// чтобы внешние источники опрашивались только при промахе.
+type StoreProvider struct {
+	store repository.SongStore
+}
+
+func NewStoreProvider(store repository.SongStore) *StoreProvider {
+	return &StoreProvider{store: store}
+}
+
+func (p *StoreProvider) Name() string {
+	return "database"
+}
+
+func (p *StoreProvider) Lookup(ctx context.Context, group, song string) (*models.Song, error) {
+	dbGroup, err := p.store.FindGroupByName(group)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := p.store.FindSong(dbGroup.ID, song)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return found, err
+}
@@ -0,0 +1,94 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// Состояния circuit breaker.
+const (
+	StateClosed   = "closed"
+	StateOpen     = "open"
+	StateHalfOpen = "half-open"
+)
+
+// breaker размыкается после threshold неудачных попыток подряд и cooldown отклоняет запросы
+// сразу. Затем пропускает одну пробную попытку: успех замыкает его, неудача снова размыкает.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, state: StateClosed}
+}
+
+// allow сообщает, можно ли выполнить попытку.
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == StateHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		if b.state != StateOpen {
+			log.Warnf("Circuit breaker opened after %d failed requests", b.failures)
+		}
+		b.state = StateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release завершает попытку, прерванную вызывающим (отмена или срок контекста запроса):
+// она ничего не говорит о сервисе, поэтому не считается ни успехом, ни неудачей.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) currentState() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.cooldown {
+		return StateHalfOpen
+	}
+	return b.state
+}
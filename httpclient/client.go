@@ -0,0 +1,199 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.New()
+
+// ErrCircuitOpen — внешний сервис недавно не отвечал, и запрос отклонён без обращения к нему.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Options — настройки Client. Нулевые Timeout, BaseBackoff, MaxRetryAfter и BreakerCooldown
+// заменяются значениями из DefaultOptions.
+type Options struct {
+	// Timeout — срок одной попытки, не считая ожидания между повторами.
+	Timeout time.Duration
+	// Retries — сколько раз повторить запрос после сетевой ошибки, 429 или 5xx.
+	Retries int
+	// BaseBackoff и MaxBackoff ограничивают экспоненциальную паузу перед повтором.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxRetryAfter — самая долгая пауза по заголовку Retry-After; если сервис просит больше, повтора нет.
+	MaxRetryAfter time.Duration
+	// BreakerThreshold — сколько неудачных попыток подряд размыкают circuit breaker; 0 отключает его.
+	BreakerThreshold int
+	// BreakerCooldown — сколько запросы отклоняются сразу после размыкания.
+	BreakerCooldown time.Duration
+}
+
+// DefaultOptions — настройки, которые используются для незаданных полей Options.
+var DefaultOptions = Options{
+	Timeout:          2 * time.Second,
+	Retries:          2,
+	BaseBackoff:      100 * time.Millisecond,
+	MaxBackoff:       2 * time.Second,
+	MaxRetryAfter:    10 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// Response — ответ внешнего сервиса, прочитанный целиком.
+type Response struct {
+	StatusCode int
+	Body       []byte
+	// FetchedAt — когда ответ получен от сервиса.
+	FetchedAt time.Time
+}
+
+// Client выполняет GET-запросы к внешнему сервису: каждая попытка ограничена Timeout и контекстом
+// запроса, сетевые ошибки, 429 и 5xx повторяются с паузой, а после серии неудач circuit breaker
+// отклоняет запросы сразу. Ответы клиент не хранит: их кэширует вызывающий.
+// Один Client используется всеми обращениями к сервису, чтобы они делили circuit breaker.
+type Client struct {
+	http    *http.Client
+	opts    Options
+	breaker *breaker
+}
+
+func New(httpClient *http.Client, opts Options) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultOptions.Timeout
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = DefaultOptions.BaseBackoff
+	}
+	if opts.MaxBackoff < opts.BaseBackoff {
+		opts.MaxBackoff = opts.BaseBackoff
+	}
+	if opts.MaxRetryAfter <= 0 {
+		opts.MaxRetryAfter = DefaultOptions.MaxRetryAfter
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = DefaultOptions.BreakerCooldown
+	}
+	return &Client{
+		http:    httpClient,
+		opts:    opts,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+	}
+}
+
+// BreakerState возвращает состояние circuit breaker: closed, open или half-open.
+func (c *Client) BreakerState() string {
+	return c.breaker.currentState()
+}
+
+// Get выполняет GET-запрос. Ответы 2xx и 4xx, кроме 429, возвращаются без повторов.
+// Если circuit breaker разомкнут, возвращается ошибка ErrCircuitOpen.
+func (c *Client) Get(ctx context.Context, url string) (*Response, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		if !c.breaker.allow() {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ErrCircuitOpen, lastErr)
+			}
+			return nil, ErrCircuitOpen
+		}
+
+		response, retryAfter, err := c.attempt(ctx, url)
+		if err == nil {
+			c.breaker.success()
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			c.breaker.release()
+			return nil, ctx.Err()
+		}
+		c.breaker.failure()
+		lastErr = err
+		if attempt >= c.opts.Retries {
+			return nil, err
+		}
+
+		wait := c.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > c.opts.MaxRetryAfter {
+				return nil, fmt.Errorf("%w (Retry-After %s is too long)", err, retryAfter)
+			}
+			wait = retryAfter
+		}
+		// повтор не успеет завершиться до срока запроса: пауза и полная попытка в него не укладываются
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait+c.opts.Timeout {
+			return nil, err
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt выполняет одну попытку. Ошибка означает, что попытку стоит повторить;
+// retryAfter — пауза, которую попросил сервис.
+func (c *Client) attempt(ctx context.Context, url string) (*Response, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	response, err := c.http.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to read response: %w", err)
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+		return nil, parseRetryAfter(response.Header.Get("Retry-After")), fmt.Errorf("responded with status %d", response.StatusCode)
+	}
+	return &Response{StatusCode: response.StatusCode, Body: body, FetchedAt: time.Now()}, 0, nil
+}
+
+// backoff — экспоненциальная пауза перед повтором со случайной половиной (equal jitter).
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.opts.BaseBackoff << attempt
+	if wait > c.opts.MaxBackoff || wait <= 0 {
+		wait = c.opts.MaxBackoff
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP-даты.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
@@ -0,0 +1,213 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer отвечает статусами из statuses по очереди, повторяя последний, и считает запросы.
+func testServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("body"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func fastOptions() Options {
+	return Options{
+		Timeout:          time.Second,
+		Retries:          2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       time.Millisecond,
+		BreakerThreshold: 0,
+	}
+}
+
+func TestGetRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		wantStatus int
+		wantErr    bool
+		wantCalls  int32
+	}{
+		{name: "success", statuses: []int{200}, wantStatus: 200, wantCalls: 1},
+		{name: "client error is not retried", statuses: []int{404}, wantStatus: 404, wantCalls: 1},
+		{name: "server error is retried", statuses: []int{500, 503, 200}, wantStatus: 200, wantCalls: 3},
+		{name: "retries are limited", statuses: []int{500}, wantErr: true, wantCalls: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := testServer(t, tt.statuses...)
+			response, err := New(nil, fastOptions()).Get(context.Background(), server.URL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got status %d", response.StatusCode)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if response.StatusCode != tt.wantStatus || string(response.Body) != "body" {
+				t.Errorf("response = %d %q, want %d", response.StatusCode, response.Body, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGetHonoursRetryAfter(t *testing.T) {
+	server, calls := testServer(t, http.StatusTooManyRequests, http.StatusOK)
+	start := time.Now()
+	response, err := New(nil, fastOptions()).Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("status = %d after %d calls", response.StatusCode, calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the Retry-After of 1s", elapsed)
+	}
+}
+
+func TestGetRejectsLongRetryAfter(t *testing.T) {
+	server, calls := testServer(t, http.StatusTooManyRequests, http.StatusOK)
+	opts := fastOptions()
+	opts.MaxRetryAfter = 500 * time.Millisecond
+	_, err := New(nil, opts).Get(context.Background(), server.URL)
+	if err == nil || !strings.Contains(err.Error(), "Retry-After") {
+		t.Fatalf("error = %v, want a Retry-After error", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestGetSkipsRetryPastDeadline(t *testing.T) {
+	server, calls := testServer(t, http.StatusInternalServerError, http.StatusOK)
+	opts := fastOptions()
+	opts.Timeout = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := New(nil, opts).Get(ctx, server.URL); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1: a retry could not finish before the deadline", calls.Load())
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	server, calls := testServer(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	opts := fastOptions()
+	opts.Retries = 0
+	opts.BreakerThreshold = 2
+	opts.BreakerCooldown = 50 * time.Millisecond
+	client := New(nil, opts)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(context.Background(), server.URL); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d: error = %v, want the server error", i, err)
+		}
+	}
+	if state := client.BreakerState(); state != StateOpen {
+		t.Fatalf("state = %s, want %s", state, StateOpen)
+	}
+	if _, err := client.Get(context.Background(), server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error = %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("calls = %d, want 2: an open breaker must not call the server", calls.Load())
+	}
+
+	time.Sleep(opts.BreakerCooldown)
+	if state := client.BreakerState(); state != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", state, StateHalfOpen)
+	}
+	if _, err := client.Get(context.Background(), server.URL); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if state := client.BreakerState(); state != StateClosed {
+		t.Errorf("state = %s, want %s", state, StateClosed)
+	}
+}
+
+func TestCancelledRequestDoesNotTripBreaker(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	opts := fastOptions()
+	opts.BreakerThreshold = 1
+	client := New(nil, opts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := client.Get(ctx, server.URL); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want %v", err, context.Canceled)
+	}
+	if state := client.BreakerState(); state != StateClosed {
+		t.Errorf("state = %s, want %s", state, StateClosed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 59*time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %s, want about 1h", date, got)
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	client := New(nil, Options{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	for attempt := 0; attempt < 40; attempt++ {
+		ceiling := min(100*time.Millisecond<<attempt, time.Second)
+		if attempt > 10 {
+			ceiling = time.Second
+		}
+		for i := 0; i < 20; i++ {
+			if wait := client.backoff(attempt); wait < ceiling/2 || wait > ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, wait, ceiling/2, ceiling)
+			}
+		}
+	}
+}
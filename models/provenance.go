@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Источники значений полей песни. Источники enrichment называются по имени провайдера (catalog, api).
+const (
+	// SourceManual — значение задал пользователь через API.
+	SourceManual = "manual"
+)
+
+// ProvenanceFields — поля песни, для которых хранится происхождение: их заполняют источники enrichment.
+var ProvenanceFields = []string{"release_date", "text", "link"}
+
+// FieldSource — откуда и когда получено значение поля.
+type FieldSource struct {
+	Source    string    `json:"source" example:"catalog"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Provenance — происхождение полей песни по их JSON-именам. Хранится в колонке jsonb.
+type Provenance map[string]FieldSource
+
+// With возвращает копию с записью для поля; исходная карта не меняется.
+func (p Provenance) With(field string, source FieldSource) Provenance {
+	result := make(Provenance, len(p)+1)
+	for key, value := range p {
+		result[key] = value
+	}
+	result[field] = source
+	return result
+}
+
+// Equal сравнивает происхождение полей; время получения сравнивается как момент, без учёта зоны.
+func (p Provenance) Equal(other Provenance) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for field, source := range p {
+		otherSource, ok := other[field]
+		if !ok || source.Source != otherSource.Source || !source.FetchedAt.Equal(otherSource.FetchedAt) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Provenance) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (p *Provenance) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*p = Provenance{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return fmt.Errorf("cannot scan %T into Provenance", value)
+}
+
+// SongField возвращает значение поля из ProvenanceFields в виде строки: дату — в формате YYYY-MM-DD
+// или пустой строкой, если она неизвестна.
+func SongField(song *Song, field string) string {
+	switch field {
+	case "release_date":
+		if song.ReleaseDate.IsZero() {
+			return ""
+		}
+		return song.ReleaseDate.Format("2006-01-02")
+	case "text":
+		return song.Text
+	case "link":
+		return song.Link
+	}
+	return ""
+}
+
+// CopySongField переносит значение поля из ProvenanceFields из src в dst.
+func CopySongField(dst *Song, src Song, field string) {
+	switch field {
+	case "release_date":
+		dst.ReleaseDate = src.ReleaseDate
+	case "text":
+		dst.Text = src.Text
+	case "link":
+		dst.Link = src.Link
+	}
+}
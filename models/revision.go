@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Действия, после которых сохраняется ревизия песни.
+const (
+	RevisionImport  = "import"
+	RevisionCreate  = "create"
+	RevisionUpdate  = "update"
+	RevisionRestore = "restore"
+	RevisionMerge   = "merge"
+)
+
+// SongRevision — состояние песни после очередного изменения и список изменённых полей.
+type SongRevision struct {
+	ID        uint         `gorm:"primaryKey" json:"-"`
+	SongID    uint         `json:"song_id" gorm:"uniqueIndex:idx_song_revisions_song_revision"`
+	Revision  int          `json:"revision" gorm:"uniqueIndex:idx_song_revisions_song_revision"`
+	CreatedAt time.Time    `json:"created_at"`
+	Author    string       `json:"author"`
+	Action    string       `json:"action"`
+	Changes   FieldChanges `json:"changes" gorm:"type:jsonb"`
+	// RestoredFrom — номер ревизии, к которой откатили песню (для Action = restore).
+	RestoredFrom int       `json:"restored_from,omitempty"`
+	GroupID      uint      `json:"group_id"`
+	Song         string    `json:"song"`
+	ReleaseDate  time.Time `json:"release_date"`
+	Text         string    `json:"text"`
+	Link         string    `json:"link"`
+}
+
+// FieldChange — старое и новое значение поля.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// FieldChanges — изменённые поля по их JSON-именам. Хранится в колонке jsonb.
+type FieldChanges map[string]FieldChange
+
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *FieldChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = FieldChanges{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("cannot scan %T into FieldChanges", value)
+}
+
+// Snapshot возвращает песню в состоянии, сохранённом в ревизии.
+func (r SongRevision) Snapshot() Song {
+	return Song{
+		ID:          r.SongID,
+		GroupID:     r.GroupID,
+		Song:        r.Song,
+		ReleaseDate: r.ReleaseDate,
+		Text:        r.Text,
+		Link:        r.Link,
+	}
+}
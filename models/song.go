@@ -2,37 +2,79 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Group struct {
-	ID        uint       `gorm:"primaryKey" json:"id"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `gorm:"index" json:"deleted_at,omitempty"`
-	Name      string     `json:"name" gorm:"unique;not null"`
-	Songs     []Song     `json:"songs"`
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string" format:"date-time"`
+	Name           string         `json:"name" gorm:"unique;not null"`
+	NormalizedName string         `json:"-" gorm:"uniqueIndex:idx_groups_normalized_name,where:deleted_at IS NULL"`
+	Songs          []Song         `json:"songs"`
 }
 
 type Song struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `gorm:"index" json:"deleted_at,omitempty"`
-	GroupID     uint       `json:"group_id" gorm:"index"`
-	Group       Group      `json:"group"`
-	Song        string     `json:"song" gorm"index"`
-	ReleaseDate time.Time  `json:"release_date" gorm:"index"`
-	Text        string     `json:"text"`
-	Link        string     `json:"link"`
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string" format:"date-time"`
+	GroupID        uint           `json:"group_id" gorm:"index"`
+	Group          Group          `json:"group"`
+	Song           string         `json:"song" gorm:"index"`
+	NormalizedSong string         `json:"-" gorm:"index"`
+	ReleaseDate    time.Time      `json:"release_date" gorm:"index"`
+	Text           string         `json:"text"`
+	Link           string         `json:"link"`
+	Lookups        int64          `json:"lookups" gorm:"not null;default:0"`
+	Version        int64          `json:"version" gorm:"not null;default:1"`
+	// Provenance — откуда получены release_date, text и link.
+	Provenance Provenance `json:"provenance" gorm:"type:jsonb;not null;default:'{}'"`
 }
 
 type SongDetail struct {
+	// ReleaseDate — дата в формате YYYY-MM-DD или пустая строка, если она неизвестна.
 	ReleaseDate string `json:"release_date"`
 	Text        string `json:"text"`
 	Link        string `json:"link"`
+	// Provenance — происхождение полей в ответе GET /info; внешний API его не присылает.
+	Provenance Provenance `json:"provenance,omitempty"`
 }
 
+// NewSongRequest — тело POST /songs. Группа создаётся, если её ещё нет.
+// Названия — до 255 символов, ссылка — абсолютный http(s) URL до 1000 символов, текст — до 64 КиБ;
+// управляющие символы запрещены, в тексте допустимы переводы строк и табуляция.
 type NewSongRequest struct {
-	Group string `json:"group" binding:"required"`
-	Song  string `json:"song" binding:"required"`
+	Group string `json:"group" binding:"required" example:"Muse" maxLength:"255"`
+	Song  string `json:"song" binding:"required" example:"Supermassive Black Hole" maxLength:"255"`
+	// ReleaseDate — дата выпуска: YYYY-MM-DD, DD.MM.YYYY или RFC 3339.
+	ReleaseDate string `json:"release_date" example:"2006-07-16"`
+	Text        string `json:"text" maxLength:"65536"`
+	Link        string `json:"link" maxLength:"1000" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+}
+
+// ReplaceSongRequest — тело PUT /songs/{id}: новое состояние песни целиком.
+// Необязательные поля, которых нет в запросе, очищаются. Ограничения полей — как у NewSongRequest.
+type ReplaceSongRequest struct {
+	GroupID     uint   `json:"group_id" binding:"required" example:"1"`
+	Song        string `json:"song" binding:"required" example:"Supermassive Black Hole" maxLength:"255"`
+	ReleaseDate string `json:"release_date" example:"2006-07-16"`
+	Text        string `json:"text" maxLength:"65536"`
+	Link        string `json:"link" maxLength:"1000" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+}
+
+// PatchSongRequest — тело PATCH /songs/{id} в виде merge patch: меняются только переданные поля,
+// null очищает release_date, text и link. Используется для документации, разбор идёт по самому патчу.
+type PatchSongRequest struct {
+	GroupID     *uint   `json:"group_id,omitempty" example:"1"`
+	Song        *string `json:"song,omitempty" maxLength:"255"`
+	ReleaseDate *string `json:"release_date,omitempty" example:"2006-07-16"`
+	Text        *string `json:"text,omitempty" maxLength:"65536"`
+	Link        *string `json:"link,omitempty" maxLength:"1000" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+}
+
+type GroupRequest struct {
+	Name string `json:"name" binding:"required" maxLength:"255"`
 }
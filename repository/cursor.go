@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"music-library/models"
+	"time"
+)
+
+// ErrInvalidCursor возвращается, если курсор не удалось разобрать
+// или он получен для другой сортировки.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SongPage — страница keyset-пагинации. Пустой курсор означает, что страницы в этом направлении нет.
+type SongPage struct {
+	Songs      []models.Song
+	NextCursor string
+	PrevCursor string
+}
+
+// cursor — позиция в упорядоченном списке песен: значение ключа сортировки и ID.
+// Клиенту передаётся в закодированном виде и не должен им интерпретироваться.
+type cursor struct {
+	SortBy   SortField `json:"s"`
+	Desc     bool      `json:"d"`
+	Key      string    `json:"k,omitempty"`
+	ID       uint      `json:"i"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor разбирает курсор и проверяет, что он соответствует сортировке фильтра.
+// Пустая строка означает первую страницу и возвращает nil.
+func decodeCursor(s string, filter SongFilter) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != sortFieldOrDefault(filter.SortBy) || c.Desc != filter.SortDesc {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+	if _, err := parseCursorKey(c.SortBy, c.Key); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func sortFieldOrDefault(field SortField) SortField {
+	if field == "" {
+		return SortByID
+	}
+	return field
+}
+
+// cursorKey возвращает значение ключа сортировки песни в виде строки.
+func cursorKey(field SortField, song models.Song, groupName string) string {
+	switch field {
+	case SortBySong:
+		return song.Song
+	case SortByGroup:
+		return groupName
+	case SortByReleaseDate:
+		return song.ReleaseDate.Format(time.RFC3339Nano)
+	case SortByCreatedAt:
+		return song.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+// parseCursorKey превращает строковый ключ курсора в значение для сравнения.
+func parseCursorKey(field SortField, key string) (interface{}, error) {
+	switch field {
+	case SortByReleaseDate, SortByCreatedAt:
+		return time.Parse(time.RFC3339Nano, key)
+	case SortBySong, SortByGroup:
+		return key, nil
+	}
+	return nil, nil
+}
+
+// buildSongPage обрезает выборку из limit+1 строк до limit и выставляет курсоры.
+// songs должны быть упорядочены в направлении запроса (для Backward — в обратном).
+func buildSongPage(songs []models.Song, after *cursor, filter SongFilter, limit int, groupName func(models.Song) string) *SongPage {
+	hasMore := len(songs) > limit
+	if hasMore {
+		songs = songs[:limit]
+	}
+	backward := after != nil && after.Backward
+	if backward {
+		for i, j := 0, len(songs)-1; i < j; i, j = i+1, j-1 {
+			songs[i], songs[j] = songs[j], songs[i]
+		}
+	}
+
+	if songs == nil {
+		songs = []models.Song{}
+	}
+	page := &SongPage{Songs: songs}
+	if len(songs) == 0 {
+		return page
+	}
+
+	field := sortFieldOrDefault(filter.SortBy)
+	makeCursor := func(song models.Song, backward bool) string {
+		return cursor{
+			SortBy:   field,
+			Desc:     filter.SortDesc,
+			Key:      cursorKey(field, song, groupName(song)),
+			ID:       song.ID,
+			Backward: backward,
+		}.encode()
+	}
+
+	first, last := songs[0], songs[len(songs)-1]
+	if backward {
+		page.NextCursor = makeCursor(last, false)
+		if hasMore {
+			page.PrevCursor = makeCursor(first, true)
+		}
+	} else {
+		if hasMore {
+			page.NextCursor = makeCursor(last, false)
+		}
+		if after != nil {
+			page.PrevCursor = makeCursor(first, true)
+		}
+	}
+	return page
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"music-library/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	byDate := SongFilter{SortBy: SortByReleaseDate}
+	date := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano)
+
+	tests := []struct {
+		name    string
+		cursor  string
+		filter  SongFilter
+		want    *cursor
+		wantErr bool
+	}{
+		{name: "first page", cursor: "", filter: byDate},
+		{
+			name:   "round trip",
+			cursor: cursor{SortBy: SortByReleaseDate, Key: date, ID: 7, Backward: true}.encode(),
+			filter: byDate,
+			want:   &cursor{SortBy: SortByReleaseDate, Key: date, ID: 7, Backward: true},
+		},
+		{
+			name:   "default sort is by id",
+			cursor: cursor{SortBy: SortByID, ID: 3}.encode(),
+			filter: SongFilter{},
+			want:   &cursor{SortBy: SortByID, ID: 3},
+		},
+		{
+			name:    "different sort field",
+			cursor:  cursor{SortBy: SortBySong, Key: "a", ID: 1}.encode(),
+			filter:  byDate,
+			wantErr: true,
+		},
+		{
+			name:    "different direction",
+			cursor:  cursor{SortBy: SortByReleaseDate, Desc: true, Key: date, ID: 1}.encode(),
+			filter:  byDate,
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			cursor:  cursor{SortBy: SortByReleaseDate, Key: "yesterday", ID: 1}.encode(),
+			filter:  byDate,
+			wantErr: true,
+		},
+		{name: "not base64", cursor: "%%%", filter: byDate, wantErr: true},
+		{name: "not JSON", cursor: "bm90IGpzb24", filter: byDate, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor, tt.filter)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCursor) {
+					t.Fatalf("error = %v, want %v", err, ErrInvalidCursor)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cursor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSongsByCursorPages(t *testing.T) {
+	store := NewMemoryStore()
+	group, err := store.GetOrCreateGroup("Muse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Одинаковые названия проверяют, что при равных ключах порядок задаёт ID
+	for i, name := range []string{"b", "a", "c", "b2", "a2", "c2", "d"} {
+		if i%2 == 1 {
+			name = "same"
+		}
+		song := &models.Song{GroupID: group.ID, Song: fmt.Sprintf("%s %d", name, i)}
+		if _, err := store.SaveSong(song, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, filter := range []SongFilter{
+		{},
+		{SortBy: SortBySong},
+		{SortBy: SortBySong, SortDesc: true},
+		{SortBy: SortByCreatedAt, SortDesc: true},
+	} {
+		t.Run(fmt.Sprintf("%s desc=%t", sortFieldOrDefault(filter.SortBy), filter.SortDesc), func(t *testing.T) {
+			all, err := store.FindSongs(filter, 1, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := songIDs(all)
+
+			var forward []uint
+			var pages []*SongPage
+			next := ""
+			for {
+				page, err := store.FindSongsByCursor(filter, next, 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				forward = append(forward, songIDs(page.Songs)...)
+				pages = append(pages, page)
+				if page.NextCursor == "" {
+					break
+				}
+				next = page.NextCursor
+			}
+			if !reflect.DeepEqual(forward, want) {
+				t.Fatalf("forward pages = %v, want %v", forward, want)
+			}
+
+			// Обратно от последней страницы проходим те же страницы
+			prev := pages[len(pages)-1].PrevCursor
+			for i := len(pages) - 2; i >= 0; i-- {
+				page, err := store.FindSongsByCursor(filter, prev, 3)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := songIDs(page.Songs), songIDs(pages[i].Songs); !reflect.DeepEqual(got, want) {
+					t.Fatalf("backward page %d = %v, want %v", i, got, want)
+				}
+				prev = page.PrevCursor
+			}
+			if prev != "" {
+				t.Errorf("first page has a previous cursor %q", prev)
+			}
+		})
+	}
+}
+
+func songIDs(songs []models.Song) []uint {
+	ids := make([]uint, 0, len(songs))
+	for _, song := range songs {
+		ids = append(ids, song.ID)
+	}
+	return ids
+}
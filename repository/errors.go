@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrNotFound возвращается, если песни или группы с таким ID или названием нет.
+	ErrNotFound = errors.New("not found")
+	// ErrGroupNotEmpty возвращается при удалении группы, у которой есть песни, без каскада.
+	ErrGroupNotEmpty = errors.New("group still has songs")
+	// ErrGroupNameTaken возвращается, если группа с таким названием уже существует.
+	ErrGroupNameTaken = errors.New("group name is already taken")
+	// ErrSongExists возвращается, если в группе уже есть песня с таким же нормализованным названием.
+	ErrSongExists = errors.New("song already exists in group")
+	// ErrNotInTrash возвращается при восстановлении записи, которой нет в корзине.
+	ErrNotInTrash = errors.New("not found in trash")
+	// ErrRevisionNotFound возвращается, если у песни нет ревизии с таким номером.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrVersionMismatch возвращается, если песню изменили после того, как клиент получил её версию.
+	ErrVersionMismatch = errors.New("song version mismatch")
+)
+
+// isUniqueViolation сообщает, что Postgres отклонил запись из-за уникального индекса.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// songConflict заменяет нарушение уникального индекса (group_id, normalized_song) на ErrSongExists.
+func songConflict(err error) error {
+	if isUniqueViolation(err) {
+		return ErrSongExists
+	}
+	return err
+}
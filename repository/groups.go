@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"music-library/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// GroupStats — группа вместе с количеством её песен.
+// Поля перечислены явно: встраивание models.Group потянуло бы связь Songs в схему gorm.
+type GroupStats struct {
+	ID             uint
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	NormalizedName string
+	SongCount      int64
+}
+
+func (repo *SongRepository) ListGroups(page, limit int) ([]GroupStats, error) {
+	offset := calculateOffset(page, limit)
+	log.WithFields(logrus.Fields{"page": page, "limit": limit}).Info("Retrieving groups")
+
+	var groups []GroupStats
+	if err := repo.DB.Model(&models.Group{}).
+		Select("groups.*, (SELECT COUNT(*) FROM songs WHERE songs.group_id = groups.id AND songs.deleted_at IS NULL) AS song_count").
+		Order("groups.name, groups.id").
+		Offset(offset).Limit(limit).
+		Scan(&groups).Error; err != nil {
+		log.WithError(err).Error("Failed to retrieve groups.")
+		return nil, fmt.Errorf("Failed to retrieve groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (repo *SongRepository) CountGroups() (int64, error) {
+	var total int64
+	if err := repo.DB.Model(&models.Group{}).Count(&total).Error; err != nil {
+		log.WithError(err).Error("Failed to count groups.")
+		return 0, fmt.Errorf("Failed to count groups: %w", err)
+	}
+	return total, nil
+}
+
+func (repo *SongRepository) RenameGroup(id uint, name string) (*models.Group, error) {
+	name = utils.CleanName(name)
+	key := utils.NameKey(name)
+	log.WithFields(logrus.Fields{"group_id": id, "name": name}).Info("Renaming group")
+
+	var group models.Group
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&group, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+		var taken int64
+		if err := tx.Model(&models.Group{}).Where("normalized_name = ? AND id <> ?", key, id).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrGroupNameTaken
+		}
+		return tx.Model(&group).Updates(map[string]interface{}{"name": name, "normalized_name": key}).Error
+	})
+	if isUniqueViolation(err) {
+		err = ErrGroupNameTaken
+	}
+	if err != nil {
+		log.WithError(err).Errorf("Failed to rename group with ID %d", id)
+		return nil, fmt.Errorf("Failed to rename group: %w", err)
+	}
+
+	log.WithField("group_id", id).Info("Group renamed successfully.")
+	return &group, nil
+}
+
+func (repo *SongRepository) DeleteGroup(id uint, cascade bool) error {
+	log.WithFields(logrus.Fields{"group_id": id, "cascade": cascade}).Info("Deleting group.")
+
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		var songs int64
+		if err := tx.Model(&models.Song{}).Where("group_id = ?", id).Count(&songs).Error; err != nil {
+			return err
+		}
+		if songs > 0 {
+			if !cascade {
+				return fmt.Errorf("%w: %d songs", ErrGroupNotEmpty, songs)
+			}
+			if err := tx.Where("group_id = ?", id).Delete(&models.Song{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Group{}, id).Error
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to delete group with ID %d", id)
+		return fmt.Errorf("Failed to delete group: %w", err)
+	}
+
+	log.WithField("group_id", id).Info("Group deleted successfully.")
+	return nil
+}
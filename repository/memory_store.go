@@ -0,0 +1,752 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"music-library/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// MemoryStore хранит песни и группы в памяти процесса.
+// Используется в тестах и локальных демо, где нет Postgres.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	songs  map[uint]models.Song
+	groups map[uint]models.Group
+	// Удалённые записи хранятся отдельно, чтобы остальные методы их не видели.
+	trashedSongs  map[uint]models.Song
+	trashedGroups map[uint]models.Group
+	// Ревизии каждой песни в порядке возрастания номера.
+	revisions      map[uint][]models.SongRevision
+	nextSongID     uint
+	nextGroupID    uint
+	nextRevisionID uint
+}
+
+func NewMemoryStore() *MemoryStore {
+	log.Info("Creating new MemoryStore")
+	return &MemoryStore{
+		songs:         make(map[uint]models.Song),
+		groups:        make(map[uint]models.Group),
+		trashedSongs:  make(map[uint]models.Song),
+		trashedGroups: make(map[uint]models.Group),
+		revisions:     make(map[uint][]models.SongRevision),
+	}
+}
+
+func (m *MemoryStore) SaveSong(song *models.Song, author string) (*models.Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	normalizeSong(song)
+	if m.songExists(song.GroupID, song.NormalizedSong, 0) {
+		return nil, fmt.Errorf("Failed to save song: %w", ErrSongExists)
+	}
+	m.nextSongID++
+	now := time.Now()
+	song.ID = m.nextSongID
+	song.CreatedAt = now
+	song.UpdatedAt = now
+	song.Version = 1
+	m.songs[song.ID] = *song
+	m.recordRevision(models.Song{}, song, author, models.RevisionCreate, 0)
+	return song, nil
+}
+
+func (m *MemoryStore) GetOrCreateSong(song *models.Song, author string) (*models.Song, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	normalizeSong(song)
+	for _, existing := range m.songs {
+		if existing.GroupID == song.GroupID && existing.NormalizedSong == song.NormalizedSong {
+			return &existing, false, nil
+		}
+	}
+
+	m.nextSongID++
+	now := time.Now()
+	song.ID = m.nextSongID
+	song.CreatedAt = now
+	song.UpdatedAt = now
+	song.Version = 1
+	m.songs[song.ID] = *song
+	m.recordRevision(models.Song{}, song, author, models.RevisionCreate, 0)
+	return song, true, nil
+}
+
+func (m *MemoryStore) GetAllSongs(page, limit int) ([]models.Song, error) {
+	return m.FindSongs(SongFilter{}, page, limit)
+}
+
+func (m *MemoryStore) FindSongs(filter SongFilter, page, limit int) ([]models.Song, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []models.Song
+	for _, song := range m.sortedSongs() {
+		if m.matches(song, filter) {
+			matched = append(matched, song)
+		}
+	}
+	m.sortSongs(matched, filter)
+	return paginate(matched, page, limit), nil
+}
+
+func (m *MemoryStore) FindSongsByCursor(filter SongFilter, after string, limit int) (*SongPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	position, err := decodeCursor(after, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var matched []models.Song
+	for _, song := range m.sortedSongs() {
+		if m.matches(song, filter) {
+			matched = append(matched, song)
+		}
+	}
+	backward := position != nil && position.Backward
+	m.sortSongs(matched, SongFilter{SortBy: filter.SortBy, SortDesc: filter.SortDesc != backward})
+
+	start := 0
+	if position != nil {
+		start = len(matched)
+		for i, song := range matched {
+			if m.afterCursor(song, filter, position) {
+				start = i
+				break
+			}
+		}
+	}
+	end := start + limit + 1
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return buildSongPage(matched[start:end], position, filter, limit, func(song models.Song) string {
+		return m.groups[song.GroupID].Name
+	}), nil
+}
+
+// afterCursor сообщает, идёт ли песня строго после позиции курсора в направлении его обхода.
+// Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) afterCursor(song models.Song, filter SongFilter, position *cursor) bool {
+	field := sortFieldOrDefault(filter.SortBy)
+	c := 0
+	switch field {
+	case SortBySong, SortByGroup:
+		c = strings.Compare(cursorKey(field, song, m.groups[song.GroupID].Name), position.Key)
+	case SortByReleaseDate, SortByCreatedAt:
+		key, _ := parseCursorKey(field, position.Key)
+		value, _ := parseCursorKey(field, cursorKey(field, song, ""))
+		c = value.(time.Time).Compare(key.(time.Time))
+	}
+	if c == 0 {
+		c = int(song.ID) - int(position.ID)
+	}
+	if filter.SortDesc != position.Backward {
+		return c < 0
+	}
+	return c > 0
+}
+
+func (m *MemoryStore) CountSongs(filter SongFilter) (int64, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var total int64
+	for _, song := range m.songs {
+		if m.matches(song, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (m *MemoryStore) GetSongByID(id uint) (*models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	song, ok := m.songs[id]
+	if !ok {
+		return nil, fmt.Errorf("song with ID %d: %w", id, ErrNotFound)
+	}
+	return &song, nil
+}
+
+func (m *MemoryStore) FindSong(groupID uint, name string) (*models.Song, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key := utils.NameKey(name)
+	for _, song := range m.sortedSongs() {
+		if song.GroupID == groupID && song.NormalizedSong == key {
+			return &song, nil
+		}
+	}
+	return nil, fmt.Errorf("song %q of group %d: %w", name, groupID, ErrNotFound)
+}
+
+func (m *MemoryStore) PatchSong(id uint, patch SongPatch, author string, ifVersion int64) (*models.Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	song, ok := m.songs[id]
+	if !ok {
+		return nil, fmt.Errorf("song with ID %d: %w", id, ErrNotFound)
+	}
+	if err := checkVersion(song, ifVersion); err != nil {
+		return nil, fmt.Errorf("Failed to update song: %w", err)
+	}
+
+	patch.apply(&song)
+	if m.songExists(song.GroupID, song.NormalizedSong, song.ID) {
+		return nil, fmt.Errorf("Failed to update song: %w", ErrSongExists)
+	}
+	song.UpdatedAt = time.Now()
+	m.recordRevision(m.songs[id], &song, author, models.RevisionUpdate, 0)
+	m.songs[id] = song
+	return &song, nil
+}
+
+func (m *MemoryStore) DeleteSong(id uint, ifVersion int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if song, ok := m.songs[id]; ok && ifVersion != 0 {
+		if err := checkVersion(song, ifVersion); err != nil {
+			return fmt.Errorf("Failed to delete song: %w", err)
+		}
+	} else if !ok && ifVersion != 0 {
+		return fmt.Errorf("song with ID %d: %w", id, ErrNotFound)
+	}
+	m.trashSong(id, time.Now())
+	return nil
+}
+
+func (m *MemoryStore) GetOrCreateGroup(name string) (*models.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = utils.CleanName(name)
+	if group := m.groupByKey(utils.NameKey(name)); group != nil {
+		return group, nil
+	}
+	// Группу из корзины восстанавливаем, а не создаём заново, как и SongRepository
+	for id, group := range m.trashedGroups {
+		if group.NormalizedName == utils.NameKey(name) {
+			group.DeletedAt = gorm.DeletedAt{}
+			m.groups[id] = group
+			delete(m.trashedGroups, id)
+			return &group, nil
+		}
+	}
+
+	m.nextGroupID++
+	now := time.Now()
+	group := models.Group{
+		ID:             m.nextGroupID,
+		Name:           name,
+		NormalizedName: utils.NameKey(name),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	m.groups[group.ID] = group
+	return &group, nil
+}
+
+func (m *MemoryStore) FindGroupByName(name string) (*models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if group := m.groupByKey(utils.NameKey(name)); group != nil {
+		return group, nil
+	}
+	return nil, fmt.Errorf("group %q: %w", name, ErrNotFound)
+}
+
+// groupByKey возвращает самую старую группу с нормализованным названием key.
+// Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) groupByKey(key string) *models.Group {
+	var found *models.Group
+	for _, group := range m.groups {
+		if group.NormalizedName == key && (found == nil || group.ID < found.ID) {
+			g := group
+			found = &g
+		}
+	}
+	return found
+}
+
+func (m *MemoryStore) GetGroupByID(id uint) (*models.Group, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	group, ok := m.groups[id]
+	if !ok {
+		return nil, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+	}
+	return &group, nil
+}
+
+func (m *MemoryStore) GroupNames(ids []uint) (map[uint]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make(map[uint]string, len(ids))
+	for _, id := range ids {
+		if group, ok := m.anyGroup(id); ok {
+			names[id] = group.Name
+		}
+	}
+	return names, nil
+}
+
+// SearchSongs ищет песни, в названии или тексте которых есть все слова запроса
+// (по префиксу, без учёта регистра). Ранг — доля совпавших слов текста, совпадения в названии весят больше.
+func (m *MemoryStore) SearchSongs(query string, page, limit int) ([]SongSearchResult, int64, error) {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return []SongSearchResult{}, 0, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []SongSearchResult
+	for _, song := range m.sortedSongs() {
+		titleWords := tokenize(song.Song)
+		textWords := tokenize(song.Text)
+
+		matchedAll := true
+		for _, term := range terms {
+			if !hasTermPrefix(titleWords, term) && !hasTermPrefix(textWords, term) {
+				matchedAll = false
+				break
+			}
+		}
+		if !matchedAll {
+			continue
+		}
+
+		titleHits := countTermHits(titleWords, terms)
+		textHits := countTermHits(textWords, terms)
+
+		rank := float64(titleHits)
+		if len(textWords) > 0 {
+			rank += float64(textHits) / float64(len(textWords))
+		}
+		results = append(results, SongSearchResult{Song: song, Rank: rank, Snippet: highlight(song.Text, terms)})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	total := int64(len(results))
+
+	offset := calculateOffset(page, limit)
+	if offset >= len(results) {
+		return []SongSearchResult{}, total, nil
+	}
+	end := len(results)
+	if offset+limit < end {
+		end = offset + limit
+	}
+	return results[offset:end], total, nil
+}
+
+func (m *MemoryStore) FindSimilar(query string, kind SimilarityKind, threshold float64, limit int) ([]SimilarMatch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := []SimilarMatch{}
+	if kind != SimilarSongs {
+		for _, group := range m.groups {
+			if sim := similarity(group.Name, query); sim >= threshold {
+				matches = append(matches, SimilarMatch{Kind: SimilarGroups, ID: group.ID, Name: group.Name, Similarity: sim})
+			}
+		}
+	}
+	if kind != SimilarGroups {
+		for _, song := range m.songs {
+			if sim := similarity(song.Song, query); sim >= threshold {
+				matches = append(matches, SimilarMatch{
+					Kind:       SimilarSongs,
+					ID:         song.ID,
+					Name:       song.Song,
+					GroupID:    song.GroupID,
+					GroupName:  m.groups[song.GroupID].Name,
+					Similarity: sim,
+				})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	return sortSimilar(matches, limit), nil
+}
+
+func (m *MemoryStore) SuggestSong(group, song string, threshold float64) (*SongSuggestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var best *SongSuggestion
+	for _, candidate := range m.sortedSongs() {
+		groupName := m.groups[candidate.GroupID].Name
+		groupSim, songSim := similarity(groupName, group), similarity(candidate.Song, song)
+		if groupSim < threshold || songSim < threshold {
+			continue
+		}
+		if sim := (groupSim + songSim) / 2; best == nil || sim > best.Similarity {
+			best = &SongSuggestion{
+				GroupID:    candidate.GroupID,
+				Group:      groupName,
+				SongID:     candidate.ID,
+				Song:       candidate.Song,
+				Similarity: sim,
+			}
+		}
+	}
+	return best, nil
+}
+
+func (m *MemoryStore) Suggest(prefix string, kind SimilarityKind, limit int) ([]Suggestion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	// matchPrefix сообщает, подходит ли название, и начинается ли оно с префикса целиком.
+	matchPrefix := func(name string) (bool, bool) {
+		lowered := strings.ToLower(name)
+		if strings.HasPrefix(lowered, prefix) {
+			return true, true
+		}
+		return strings.Contains(lowered, " "+prefix), false
+	}
+
+	suggestions := []Suggestion{}
+	if kind != SimilarSongs {
+		// как и в базе, популярность считается только для первых по названию кандидатов
+		var byName, byWord []models.Group
+		for _, group := range m.groups {
+			if ok, namePrefix := matchPrefix(group.Name); ok && namePrefix {
+				byName = append(byName, group)
+			} else if ok {
+				byWord = append(byWord, group)
+			}
+		}
+		popularity := make(map[uint]int64)
+		for _, song := range m.songs {
+			popularity[song.GroupID] += song.Lookups + 1
+		}
+		for i, candidates := range [][]models.Group{byName, byWord} {
+			sort.Slice(candidates, func(i, j int) bool {
+				return strings.ToLower(candidates[i].Name) < strings.ToLower(candidates[j].Name)
+			})
+			if len(candidates) > limit*suggestCandidates {
+				candidates = candidates[:limit*suggestCandidates]
+			}
+			for _, group := range candidates {
+				suggestions = append(suggestions, Suggestion{
+					Kind:       SimilarGroups,
+					ID:         group.ID,
+					Text:       group.Name,
+					Popularity: popularity[group.ID],
+					NamePrefix: i == 0,
+				})
+			}
+		}
+	}
+	if kind != SimilarGroups {
+		for _, song := range m.songs {
+			if ok, namePrefix := matchPrefix(song.Song); ok {
+				suggestions = append(suggestions, Suggestion{
+					Kind:       SimilarSongs,
+					ID:         song.ID,
+					Text:       song.Song,
+					GroupName:  m.groups[song.GroupID].Name,
+					Popularity: song.Lookups,
+					NamePrefix: namePrefix,
+				})
+			}
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].ID < suggestions[j].ID })
+	return sortSuggestions(suggestions, limit), nil
+}
+
+func (m *MemoryStore) RecordLookup(songID uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if song, ok := m.songs[songID]; ok {
+		song.Lookups++
+		m.songs[songID] = song
+	}
+	return nil
+}
+
+func (m *MemoryStore) ListGroups(page, limit int) ([]GroupStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[uint]int64)
+	for _, song := range m.songs {
+		counts[song.GroupID]++
+	}
+
+	groups := make([]GroupStats, 0, len(m.groups))
+	for _, group := range m.groups {
+		groups = append(groups, groupStats(group, counts[group.ID]))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].ID < groups[j].ID
+	})
+
+	return paginate(groups, page, limit), nil
+}
+
+func (m *MemoryStore) CountGroups() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return int64(len(m.groups)), nil
+}
+
+func (m *MemoryStore) RenameGroup(id uint, name string) (*models.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[id]
+	if !ok {
+		return nil, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+	}
+	name = utils.CleanName(name)
+	key := utils.NameKey(name)
+	for _, other := range m.groups {
+		if other.ID != id && other.NormalizedName == key {
+			return nil, fmt.Errorf("Failed to rename group: %w", ErrGroupNameTaken)
+		}
+	}
+	group.Name = name
+	group.NormalizedName = key
+	group.UpdatedAt = time.Now()
+	m.groups[id] = group
+	return &group, nil
+}
+
+func (m *MemoryStore) DeleteGroup(id uint, cascade bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var songIDs []uint
+	for _, song := range m.songs {
+		if song.GroupID == id {
+			songIDs = append(songIDs, song.ID)
+		}
+	}
+	if len(songIDs) > 0 && !cascade {
+		return fmt.Errorf("Failed to delete group: %w: %d songs", ErrGroupNotEmpty, len(songIDs))
+	}
+	now := time.Now()
+	for _, songID := range songIDs {
+		m.trashSong(songID, now)
+	}
+	m.trashGroup(id, now)
+	return nil
+}
+
+func groupStats(group models.Group, songCount int64) GroupStats {
+	return GroupStats{
+		ID:             group.ID,
+		CreatedAt:      group.CreatedAt,
+		UpdatedAt:      group.UpdatedAt,
+		Name:           group.Name,
+		NormalizedName: group.NormalizedName,
+		SongCount:      songCount,
+	}
+}
+
+// songExists сообщает, есть ли в группе другая песня с ключом key — аналог
+// уникального индекса (group_id, normalized_song). Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) songExists(groupID uint, key string, exceptID uint) bool {
+	for _, song := range m.songs {
+		if song.ID != exceptID && song.GroupID == groupID && song.NormalizedSong == key {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedSongs возвращает копии песен в порядке возрастания ID.
+// Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) sortedSongs() []models.Song {
+	songs := make([]models.Song, 0, len(m.songs))
+	for _, song := range m.songs {
+		songs = append(songs, song)
+	}
+	sort.Slice(songs, func(i, j int) bool { return songs[i].ID < songs[j].ID })
+	return songs
+}
+
+func (m *MemoryStore) matches(song models.Song, filter SongFilter) bool {
+	match := func(value, pattern string) bool {
+		if pattern == "" {
+			return true
+		}
+		if filter.Match == MatchExact {
+			return value == pattern
+		}
+		return containsFold(value, pattern)
+	}
+
+	if !match(m.groups[song.GroupID].Name, filter.Group) ||
+		!match(song.Song, filter.Song) ||
+		!match(song.Link, filter.Link) {
+		return false
+	}
+	if filter.GroupID != 0 && song.GroupID != filter.GroupID {
+		return false
+	}
+	if filter.Text != "" && !containsFold(song.Text, filter.Text) {
+		return false
+	}
+	if filter.ReleasedFrom != nil && song.ReleaseDate.Before(*filter.ReleasedFrom) {
+		return false
+	}
+	if filter.ReleasedTo != nil && song.ReleaseDate.After(*filter.ReleasedTo) {
+		return false
+	}
+	return true
+}
+
+// sortSongs упорядочивает песни так же, как applySongFilter: по полю фильтра, затем по ID.
+// Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) sortSongs(songs []models.Song, filter SongFilter) {
+	compare := func(a, b models.Song) int {
+		switch filter.SortBy {
+		case SortBySong:
+			return strings.Compare(a.Song, b.Song)
+		case SortByGroup:
+			return strings.Compare(m.groups[a.GroupID].Name, m.groups[b.GroupID].Name)
+		case SortByReleaseDate:
+			return a.ReleaseDate.Compare(b.ReleaseDate)
+		case SortByCreatedAt:
+			return a.CreatedAt.Compare(b.CreatedAt)
+		}
+		return 0
+	}
+	sort.SliceStable(songs, func(i, j int) bool {
+		c := compare(songs[i], songs[j])
+		if c == 0 {
+			c = int(songs[i].ID) - int(songs[j].ID)
+		}
+		if filter.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func paginate[T any](items []T, page, limit int) []T {
+	offset := calculateOffset(page, limit)
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return items[offset:end]
+}
+
+// tokenize разбивает строку на слова в нижнем регистре.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hasTermPrefix(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(word string, terms []string) bool {
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func countTermHits(words, terms []string) int {
+	hits := 0
+	for _, word := range words {
+		if hasAnyPrefix(word, terms) {
+			hits++
+		}
+	}
+	return hits
+}
+
+// highlight возвращает фрагмент текста вокруг первого совпадения, экранированный для HTML,
+// выделяя совпавшие слова тегом <mark>, как SongRepository.
+func highlight(text string, terms []string) string {
+	const window = 10
+
+	words := strings.Fields(text)
+	first := -1
+	marked := make([]string, len(words))
+	for i, word := range words {
+		marked[i] = word
+		if countTermHits(tokenize(word), terms) > 0 {
+			marked[i] = highlightStart + word + highlightStop
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	start := first - window/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + window
+	if end > len(marked) {
+		end = len(marked)
+	}
+	return markHighlights(strings.Join(marked[start:end], " "))
+}
@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"music-library/utils"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// MergeReport описывает результат слияния групп или песен.
+type MergeReport struct {
+	TargetID     uint   `json:"target_id"`
+	GroupsMerged []uint `json:"groups_merged"`
+	SongsMoved   int64  `json:"songs_moved"`
+	SongsMerged  int64  `json:"songs_merged"`
+}
+
+// DuplicateGroups — группы с одинаковым нормализованным названием.
+type DuplicateGroups struct {
+	Key    string
+	Groups []GroupStats
+}
+
+// mergeSongFields переносит в keeper лучшие поля дубликата:
+// заполняет пустые поля, оставляет более длинный текст и суммирует обращения.
+// Вместе с полем переносится и его происхождение.
+func mergeSongFields(keeper *models.Song, dup models.Song) {
+	take := map[string]bool{
+		"release_date": keeper.ReleaseDate.IsZero() && !dup.ReleaseDate.IsZero(),
+		"text":         len(dup.Text) > len(keeper.Text),
+		"link":         keeper.Link == "" && dup.Link != "",
+	}
+	for _, field := range models.ProvenanceFields {
+		if !take[field] {
+			continue
+		}
+		models.CopySongField(keeper, dup, field)
+		if source, ok := dup.Provenance[field]; ok {
+			keeper.Provenance = keeper.Provenance.With(field, source)
+		}
+	}
+	keeper.Lookups += dup.Lookups
+}
+
+// duplicateSongSets разбивает песни группы на наборы с одинаковым нормализованным названием.
+// Песни в наборе упорядочены по ID, первая остаётся, остальные сливаются в неё.
+func duplicateSongSets(songs []models.Song) [][]models.Song {
+	sort.Slice(songs, func(i, j int) bool { return songs[i].ID < songs[j].ID })
+
+	byKey := make(map[string][]models.Song)
+	var keys []string
+	for _, song := range songs {
+		key := song.NormalizedSong
+		if key == "" {
+			key = utils.NameKey(song.Song)
+		}
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], song)
+	}
+
+	var sets [][]models.Song
+	for _, key := range keys {
+		if len(byKey[key]) > 1 {
+			sets = append(sets, byKey[key])
+		}
+	}
+	return sets
+}
+
+func (repo *SongRepository) FindDuplicateGroups() ([]DuplicateGroups, error) {
+	var groups []GroupStats
+	if err := repo.DB.Model(&models.Group{}).
+		Select("groups.*, (SELECT COUNT(*) FROM songs WHERE songs.group_id = groups.id AND songs.deleted_at IS NULL) AS song_count").
+		Where("normalized_name IN (?)", repo.DB.Model(&models.Group{}).
+			Select("normalized_name").Group("normalized_name").Having("COUNT(*) > 1")).
+		Order("groups.normalized_name, groups.id").
+		Scan(&groups).Error; err != nil {
+		log.WithError(err).Error("Failed to find duplicate groups.")
+		return nil, fmt.Errorf("Failed to find duplicate groups: %w", err)
+	}
+
+	duplicates := []DuplicateGroups{}
+	for _, group := range groups {
+		key := group.NormalizedName
+		if n := len(duplicates); n > 0 && duplicates[n-1].Key == key {
+			duplicates[n-1].Groups = append(duplicates[n-1].Groups, group)
+			continue
+		}
+		duplicates = append(duplicates, DuplicateGroups{Key: key, Groups: []GroupStats{group}})
+	}
+	return duplicates, nil
+}
+
+func (repo *SongRepository) MergeGroups(targetID uint, sourceIDs []uint) (*MergeReport, error) {
+	log.WithFields(logrus.Fields{"target_id": targetID, "source_ids": sourceIDs}).Info("Merging groups")
+
+	report := &MergeReport{TargetID: targetID, GroupsMerged: []uint{}}
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		var target models.Group
+		if err := tx.First(&target, targetID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("group with ID %d: %w", targetID, ErrNotFound)
+			}
+			return err
+		}
+
+		// Без явного списка сливаем все группы с тем же нормализованным названием.
+		if len(sourceIDs) == 0 {
+			if err := tx.Model(&models.Group{}).
+				Where("normalized_name = ? AND id <> ?", target.NormalizedName, targetID).
+				Order("id").Pluck("id", &sourceIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, id := range sourceIDs {
+			if id == targetID {
+				continue
+			}
+			var source models.Group
+			if err := tx.First(&source, id).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+				}
+				return err
+			}
+
+			// Дубликаты сливаем до переноса, иначе перенос нарушит уникальный индекс (group_id, normalized_song)
+			var songs []models.Song
+			if err := tx.Where("group_id IN ?", []uint{targetID, id}).Find(&songs).Error; err != nil {
+				return err
+			}
+			merged, err := mergeSongSets(tx, songs)
+			if err != nil {
+				return err
+			}
+			var moving []models.Song
+			if err := tx.Where("group_id = ?", id).Find(&moving).Error; err != nil {
+				return err
+			}
+			moved := tx.Model(&models.Song{}).Where("group_id = ?", id).Update("group_id", targetID)
+			if moved.Error != nil {
+				return moved.Error
+			}
+			for _, song := range moving {
+				after := song
+				after.GroupID = targetID
+				if err := recordRevision(tx, song, &after, SystemAuthor, models.RevisionMerge, 0); err != nil {
+					return err
+				}
+			}
+			if err := tx.Delete(&models.Group{}, id).Error; err != nil {
+				return err
+			}
+			report.SongsMerged += merged
+			report.SongsMoved += moved.RowsAffected
+			report.GroupsMerged = append(report.GroupsMerged, id)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to merge groups into group with ID %d", targetID)
+		return nil, fmt.Errorf("Failed to merge groups: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{"target_id": targetID, "songs_moved": report.SongsMoved}).Info("Groups merged successfully.")
+	return report, nil
+}
+
+func (repo *SongRepository) MergeDuplicateSongs(groupID uint) (*MergeReport, error) {
+	log.WithField("group_id", groupID).Info("Merging duplicate songs")
+
+	report := &MergeReport{TargetID: groupID, GroupsMerged: []uint{}}
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Group{}, groupID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("group with ID %d: %w", groupID, ErrNotFound)
+			}
+			return err
+		}
+		var songs []models.Song
+		if err := tx.Where("group_id = ?", groupID).Find(&songs).Error; err != nil {
+			return err
+		}
+		merged, err := mergeSongSets(tx, songs)
+		report.SongsMerged = merged
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to merge songs of group with ID %d", groupID)
+		return nil, fmt.Errorf("Failed to merge songs: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{"group_id": groupID, "songs_merged": report.SongsMerged}).Info("Songs merged successfully.")
+	return report, nil
+}
+
+// mergeSongSets сливает песни с одинаковым нормализованным названием
+// в песню с наименьшим ID и возвращает количество удалённых дубликатов.
+func mergeSongSets(tx *gorm.DB, songs []models.Song) (int64, error) {
+	var merged int64
+	for _, set := range duplicateSongSets(songs) {
+		keeper := set[0]
+		before := keeper
+		ids := make([]uint, 0, len(set)-1)
+		for _, dup := range set[1:] {
+			mergeSongFields(&keeper, dup)
+			ids = append(ids, dup.ID)
+		}
+		if err := tx.Model(&models.Song{}).Where("id = ?", keeper.ID).Updates(map[string]interface{}{
+			"release_date": keeper.ReleaseDate,
+			"text":         keeper.Text,
+			"link":         keeper.Link,
+			"lookups":      keeper.Lookups,
+			"provenance":   keeper.Provenance,
+		}).Error; err != nil {
+			return 0, err
+		}
+		if err := tx.Delete(&models.Song{}, ids).Error; err != nil {
+			return 0, err
+		}
+		if err := recordRevision(tx, before, &keeper, SystemAuthor, models.RevisionMerge, 0); err != nil {
+			return 0, err
+		}
+		merged += int64(len(ids))
+	}
+	return merged, nil
+}
+
+func (m *MemoryStore) FindDuplicateGroups() ([]DuplicateGroups, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[uint]int64)
+	for _, song := range m.songs {
+		counts[song.GroupID]++
+	}
+
+	byKey := make(map[string][]GroupStats)
+	for _, group := range m.groups {
+		byKey[group.NormalizedName] = append(byKey[group.NormalizedName], groupStats(group, counts[group.ID]))
+	}
+
+	duplicates := []DuplicateGroups{}
+	for key, groups := range byKey {
+		if len(groups) < 2 {
+			continue
+		}
+		sort.Slice(groups, func(i, j int) bool { return groups[i].ID < groups[j].ID })
+		duplicates = append(duplicates, DuplicateGroups{Key: key, Groups: groups})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Key < duplicates[j].Key })
+	return duplicates, nil
+}
+
+func (m *MemoryStore) MergeGroups(targetID uint, sourceIDs []uint) (*MergeReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	target, ok := m.groups[targetID]
+	if !ok {
+		return nil, fmt.Errorf("Failed to merge groups: group with ID %d: %w", targetID, ErrNotFound)
+	}
+	if len(sourceIDs) == 0 {
+		for id, group := range m.groups {
+			if id != targetID && group.NormalizedName == target.NormalizedName {
+				sourceIDs = append(sourceIDs, id)
+			}
+		}
+		sort.Slice(sourceIDs, func(i, j int) bool { return sourceIDs[i] < sourceIDs[j] })
+	}
+	for _, id := range sourceIDs {
+		if _, ok := m.groups[id]; !ok {
+			return nil, fmt.Errorf("Failed to merge groups: group with ID %d: %w", id, ErrNotFound)
+		}
+	}
+
+	report := &MergeReport{TargetID: targetID, GroupsMerged: []uint{}}
+	for _, id := range sourceIDs {
+		if id == targetID {
+			continue
+		}
+		report.SongsMerged += m.mergeSongSets(m.groupSongs(targetID, id))
+		for songID, song := range m.songs {
+			if song.GroupID == id {
+				before := song
+				song.GroupID = targetID
+				m.recordRevision(before, &song, SystemAuthor, models.RevisionMerge, 0)
+				m.songs[songID] = song
+				report.SongsMoved++
+			}
+		}
+		m.trashGroup(id, time.Now())
+		report.GroupsMerged = append(report.GroupsMerged, id)
+	}
+	return report, nil
+}
+
+func (m *MemoryStore) MergeDuplicateSongs(groupID uint) (*MergeReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.groups[groupID]; !ok {
+		return nil, fmt.Errorf("Failed to merge songs: group with ID %d: %w", groupID, ErrNotFound)
+	}
+	return &MergeReport{
+		TargetID:     groupID,
+		GroupsMerged: []uint{},
+		SongsMerged:  m.mergeSongSets(m.groupSongs(groupID)),
+	}, nil
+}
+
+// groupSongs возвращает песни перечисленных групп. Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) groupSongs(groupIDs ...uint) []models.Song {
+	var songs []models.Song
+	for _, song := range m.songs {
+		for _, id := range groupIDs {
+			if song.GroupID == id {
+				songs = append(songs, song)
+			}
+		}
+	}
+	return songs
+}
+
+// mergeSongSets — аналог одноимённой функции для gorm. Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) mergeSongSets(songs []models.Song) int64 {
+	var merged int64
+	for _, set := range duplicateSongSets(songs) {
+		keeper := set[0]
+		for _, dup := range set[1:] {
+			mergeSongFields(&keeper, dup)
+			m.trashSong(dup.ID, time.Now())
+			merged++
+		}
+		m.recordRevision(set[0], &keeper, SystemAuthor, models.RevisionMerge, 0)
+		m.songs[keeper.ID] = keeper
+	}
+	return merged
+}
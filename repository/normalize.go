@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"music-library/models"
+	"music-library/utils"
+)
+
+// normalizeSong приводит название песни к виду для хранения и заполняет ключ дубликатов.
+func normalizeSong(song *models.Song) {
+	if song.Song == "" {
+		return
+	}
+	song.Song = utils.CleanName(song.Song)
+	song.NormalizedSong = utils.NameKey(song.Song)
+}
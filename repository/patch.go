@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"music-library/models"
+	"music-library/utils"
+	"time"
+)
+
+// SongPatch — проверенные изменения полей песни для PatchSong. Поле nil не меняется.
+// Менять через PatchSong можно только эти поля: служебные колонки (id, даты, версия) ведёт хранилище.
+type SongPatch struct {
+	GroupID     *uint
+	Song        *string
+	ReleaseDate *time.Time
+	Text        *string
+	Link        *string
+	// Provenance — происхождение новых значений release_date, text и link.
+	// Поля без записи считаются изменёнными вручную (models.SourceManual).
+	Provenance models.Provenance
+}
+
+// IsEmpty сообщает, что патч ничего не меняет.
+func (p SongPatch) IsEmpty() bool {
+	return p.GroupID == nil && p.Song == nil && p.ReleaseDate == nil && p.Text == nil && p.Link == nil
+}
+
+// columns возвращает изменения в виде колонок для gorm Updates, вместе с normalized_song.
+func (p SongPatch) columns() map[string]interface{} {
+	columns := map[string]interface{}{}
+	if p.GroupID != nil {
+		columns["group_id"] = *p.GroupID
+	}
+	if p.Song != nil {
+		name := utils.CleanName(*p.Song)
+		columns["song"] = name
+		columns["normalized_song"] = utils.NameKey(name)
+	}
+	if p.ReleaseDate != nil {
+		columns["release_date"] = *p.ReleaseDate
+	}
+	if p.Text != nil {
+		columns["text"] = *p.Text
+	}
+	if p.Link != nil {
+		columns["link"] = *p.Link
+	}
+	return columns
+}
+
+// provenance возвращает происхождение полей песни после патча или nil, если патч не меняет
+// поля из models.ProvenanceFields.
+func (p SongPatch) provenance(current models.Provenance, now time.Time) models.Provenance {
+	changed := map[string]bool{
+		"release_date": p.ReleaseDate != nil,
+		"text":         p.Text != nil,
+		"link":         p.Link != nil,
+	}
+	var result models.Provenance
+	for _, field := range models.ProvenanceFields {
+		if !changed[field] {
+			continue
+		}
+		if result == nil {
+			result = current
+		}
+		source, ok := p.Provenance[field]
+		if !ok {
+			source = models.FieldSource{Source: models.SourceManual, FetchedAt: now}
+		}
+		result = result.With(field, source)
+	}
+	return result
+}
+
+// apply переносит изменения в песню; используется MemoryStore.
+func (p SongPatch) apply(song *models.Song) {
+	if p.GroupID != nil {
+		song.GroupID = *p.GroupID
+	}
+	if p.Song != nil {
+		song.Song = *p.Song
+		normalizeSong(song)
+	}
+	if p.ReleaseDate != nil {
+		song.ReleaseDate = *p.ReleaseDate
+	}
+	if p.Text != nil {
+		song.Text = *p.Text
+	}
+	if p.Link != nil {
+		song.Link = *p.Link
+	}
+	if provenance := p.provenance(song.Provenance, time.Now()); provenance != nil {
+		song.Provenance = provenance
+	}
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SystemAuthor — автор изменений, которые делает сам сервис: импорт из внешнего API и слияние дубликатов.
+const SystemAuthor = "system"
+
+// SongChanges сравнивает поля песни до и после изменения. Ключи совпадают с JSON-именами полей models.Song.
+func SongChanges(before, after models.Song) models.FieldChanges {
+	changes := models.FieldChanges{}
+	if before.GroupID != after.GroupID {
+		changes["group_id"] = models.FieldChange{Old: before.GroupID, New: after.GroupID}
+	}
+	if before.Song != after.Song {
+		changes["song"] = models.FieldChange{Old: before.Song, New: after.Song}
+	}
+	if !before.ReleaseDate.Equal(after.ReleaseDate) {
+		changes["release_date"] = models.FieldChange{Old: dateValue(before.ReleaseDate), New: dateValue(after.ReleaseDate)}
+	}
+	if before.Text != after.Text {
+		changes["text"] = models.FieldChange{Old: before.Text, New: after.Text}
+	}
+	if before.Link != after.Link {
+		changes["link"] = models.FieldChange{Old: before.Link, New: after.Link}
+	}
+	return changes
+}
+
+func dateValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
+
+// newRevision описывает переход песни из before в after. Возвращает false,
+// если изменение не затронуло ни одного поля и ревизию сохранять не нужно.
+func newRevision(before, after models.Song, author, action string) (models.SongRevision, bool) {
+	changes := SongChanges(before, after)
+	if len(changes) == 0 && action != models.RevisionCreate {
+		return models.SongRevision{}, false
+	}
+	return models.SongRevision{
+		SongID:      after.ID,
+		Author:      author,
+		Action:      action,
+		Changes:     changes,
+		GroupID:     after.GroupID,
+		Song:        after.Song,
+		ReleaseDate: after.ReleaseDate,
+		Text:        after.Text,
+		Link:        after.Link,
+	}, true
+}
+
+// versionChanged сообщает, нужно ли увеличить версию песни: её меняет любое сохранённое
+// изменение, включая происхождение полей, которое в ревизию не попадает.
+func versionChanged(before, after models.Song, action string, changed bool) bool {
+	return action != models.RevisionCreate && (changed || !before.Provenance.Equal(after.Provenance))
+}
+
+// recordRevision сохраняет ревизию со следующим номером для песни, если изменились её поля,
+// и увеличивает версию песни при любом изменении, кроме создания.
+// Вызывается в транзакции изменения, строка песни при этом должна быть заблокирована.
+func recordRevision(tx *gorm.DB, before models.Song, after *models.Song, author, action string, restoredFrom int) error {
+	revision, changed := newRevision(before, *after, author, action)
+	if versionChanged(before, *after, action, changed) {
+		if err := tx.Model(&models.Song{}).Where("id = ?", after.ID).
+			UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+			return err
+		}
+		after.Version = before.Version + 1
+	}
+	if !changed {
+		return nil
+	}
+	revision.RestoredFrom = restoredFrom
+	if err := tx.Model(&models.SongRevision{}).Where("song_id = ?", after.ID).
+		Select("COALESCE(MAX(revision), 0) + 1").Scan(&revision.Revision).Error; err != nil {
+		return err
+	}
+	return tx.Create(&revision).Error
+}
+
+// restoredProvenance помечает поля, которые меняет откат к ревизии, как изменённые вручную.
+func restoredProvenance(before, after models.Song, now time.Time) models.Provenance {
+	provenance := before.Provenance
+	for _, field := range models.ProvenanceFields {
+		if models.SongField(&before, field) != models.SongField(&after, field) {
+			provenance = provenance.With(field, models.FieldSource{Source: models.SourceManual, FetchedAt: now})
+		}
+	}
+	return provenance
+}
+
+// lockSong загружает неудалённую песню и блокирует её строку до конца транзакции.
+func lockSong(tx *gorm.DB, id uint) (models.Song, error) {
+	var song models.Song
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&song, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return song, fmt.Errorf("song with ID %d: %w", id, ErrNotFound)
+		}
+		return song, err
+	}
+	return song, nil
+}
+
+// checkVersion сравнивает версию песни с ожидаемой клиентом (If-Match); 0 — без проверки.
+func checkVersion(song models.Song, ifVersion int64) error {
+	if ifVersion != 0 && song.Version != ifVersion {
+		return fmt.Errorf("%w: expected version %d, current %d", ErrVersionMismatch, ifVersion, song.Version)
+	}
+	return nil
+}
+
+func (repo *SongRepository) ListRevisions(songID uint) ([]models.SongRevision, error) {
+	revisions := []models.SongRevision{}
+	if err := repo.DB.Where("song_id = ?", songID).Order("revision DESC").Find(&revisions).Error; err != nil {
+		log.WithError(err).Errorf("Failed to retrieve revisions of song with ID %d", songID)
+		return nil, fmt.Errorf("Failed to retrieve revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+func (repo *SongRepository) GetRevision(songID uint, revision int) (*models.SongRevision, error) {
+	var rev models.SongRevision
+	if err := repo.DB.Where("song_id = ? AND revision = ?", songID, revision).First(&rev).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("revision %d of song %d: %w", revision, songID, ErrRevisionNotFound)
+		}
+		log.WithError(err).Error("Failed to fetch revision.")
+		return nil, fmt.Errorf("Failed to fetch revision: %w", err)
+	}
+	return &rev, nil
+}
+
+func (repo *SongRepository) RestoreRevision(songID uint, revision int, author string) (*models.Song, error) {
+	log.WithFields(logrus.Fields{"song_id": songID, "revision": revision}).Info("Restoring song revision")
+
+	var song models.Song
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := lockSong(tx, songID)
+		if err != nil {
+			return err
+		}
+		var rev models.SongRevision
+		if err := tx.Where("song_id = ? AND revision = ?", songID, revision).First(&rev).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("revision %d of song %d: %w", revision, songID, ErrRevisionNotFound)
+			}
+			return err
+		}
+
+		song = rev.Snapshot()
+		song.CreatedAt = before.CreatedAt
+		song.Lookups = before.Lookups
+		song.Version = before.Version
+		// Группа ревизии могла попасть в корзину или быть удалена окончательно
+		groupID, err := restoreGroup(tx, song.GroupID)
+		if err == gorm.ErrRecordNotFound {
+			groupID = before.GroupID
+		} else if err != nil {
+			return err
+		}
+		song.GroupID = groupID
+
+		normalizeSong(&song)
+		song.Provenance = restoredProvenance(before, song, time.Now())
+		if err := tx.Model(&models.Song{}).Where("id = ?", songID).
+			Select("group_id", "song", "normalized_song", "release_date", "text", "link", "provenance", "updated_at").
+			Updates(&song).Error; err != nil {
+			return songConflict(err)
+		}
+		if err := tx.First(&song, songID).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, before, &song, author, models.RevisionRestore, revision)
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to restore revision %d of song with ID %d", revision, songID)
+		return nil, fmt.Errorf("Failed to restore revision: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{"song_id": songID, "revision": revision}).Info("Song revision restored successfully.")
+	return &song, nil
+}
+
+func (m *MemoryStore) ListRevisions(songID uint) ([]models.SongRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.revisions[songID]
+	revisions := make([]models.SongRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+	return revisions, nil
+}
+
+func (m *MemoryStore) GetRevision(songID uint, revision int) (*models.SongRevision, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, rev := range m.revisions[songID] {
+		if rev.Revision == revision {
+			return &rev, nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d of song %d: %w", revision, songID, ErrRevisionNotFound)
+}
+
+func (m *MemoryStore) RestoreRevision(songID uint, revision int, author string) (*models.Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before, ok := m.songs[songID]
+	if !ok {
+		return nil, fmt.Errorf("Failed to restore revision: song with ID %d: %w", songID, ErrNotFound)
+	}
+	var rev *models.SongRevision
+	for i := range m.revisions[songID] {
+		if m.revisions[songID][i].Revision == revision {
+			rev = &m.revisions[songID][i]
+		}
+	}
+	if rev == nil {
+		return nil, fmt.Errorf("Failed to restore revision: revision %d of song %d: %w", revision, songID, ErrRevisionNotFound)
+	}
+
+	song := rev.Snapshot()
+	song.CreatedAt = before.CreatedAt
+	song.Lookups = before.Lookups
+	group, trashed := m.trashedGroups[song.GroupID]
+	if _, ok := m.groups[song.GroupID]; !ok && !trashed {
+		song.GroupID = before.GroupID
+	}
+	normalizeSong(&song)
+	if m.songExists(song.GroupID, song.NormalizedSong, songID) {
+		return nil, fmt.Errorf("Failed to restore revision: %w", ErrSongExists)
+	}
+	if trashed {
+		group.DeletedAt = gorm.DeletedAt{}
+		m.groups[group.ID] = group
+		delete(m.trashedGroups, group.ID)
+	}
+
+	song.UpdatedAt = time.Now()
+	song.Version = before.Version
+	song.Provenance = restoredProvenance(before, song, song.UpdatedAt)
+	m.recordRevision(before, &song, author, models.RevisionRestore, revision)
+	m.songs[songID] = song
+	return &song, nil
+}
+
+// recordRevision — аналог одноимённой функции для gorm. Вызывающий должен удерживать m.mu
+// и сохранить after в m.songs после вызова.
+func (m *MemoryStore) recordRevision(before models.Song, after *models.Song, author, action string, restoredFrom int) {
+	revision, changed := newRevision(before, *after, author, action)
+	if versionChanged(before, *after, action, changed) {
+		after.Version = before.Version + 1
+	}
+	if !changed {
+		return
+	}
+	m.nextRevisionID++
+	revision.ID = m.nextRevisionID
+	revision.Revision = len(m.revisions[after.ID]) + 1
+	revision.RestoredFrom = restoredFrom
+	revision.CreatedAt = time.Now()
+	m.revisions[after.ID] = append(m.revisions[after.ID], revision)
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"fmt"
+	"html"
+	"music-library/models"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// searchConfig — конфигурация текстового поиска Postgres, совпадающая с songs.search_vector.
+const searchConfig = "russian"
+
+// Границы совпадений во фрагментах поиска. Управляющие символы не встречаются в тексте песен
+// (их отклоняет валидация), поэтому фрагмент можно экранировать для HTML и только потом
+// заменить границы на <mark>: ts_headline сам текст вокруг совпадений не экранирует.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// headlineOptions задаёт вид фрагментов ts_headline.
+const headlineOptions = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=20, MinWords=5, MaxFragments=2, FragmentDelimiter=" … "`
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// markHighlights экранирует фрагмент для HTML и выделяет совпадения тегом <mark>.
+func markHighlights(snippet string) string {
+	return highlightMarks.Replace(html.EscapeString(snippet))
+}
+
+// SongSearchResult — песня, найденная полнотекстовым поиском.
+type SongSearchResult struct {
+	Song    models.Song
+	Rank    float64
+	Snippet string
+}
+
+type songSearchRow struct {
+	models.Song
+	Rank    float64
+	Snippet string
+}
+
+func (repo *SongRepository) SearchSongs(query string, page, limit int) ([]SongSearchResult, int64, error) {
+	offset := calculateOffset(page, limit)
+	log.WithFields(logrus.Fields{"query": query, "page": page, "limit": limit}).Info("Full-text searching songs")
+
+	var total int64
+	if err := repo.DB.Raw(
+		`SELECT COUNT(*) FROM songs WHERE search_vector @@ websearch_to_tsquery(?::regconfig, ?) AND deleted_at IS NULL`,
+		searchConfig, query,
+	).Scan(&total).Error; err != nil {
+		log.WithError(err).Error("Failed to count search results.")
+		return nil, 0, fmt.Errorf("Failed to search songs: %w", err)
+	}
+
+	var rows []songSearchRow
+	if err := repo.DB.Raw(`
+		SELECT songs.*,
+		       ts_rank(songs.search_vector, q) AS rank,
+		       ts_headline(?::regconfig, coalesce(songs.text, ''), q, ?) AS snippet
+		FROM songs, websearch_to_tsquery(?::regconfig, ?) AS q
+		WHERE songs.search_vector @@ q AND songs.deleted_at IS NULL
+		ORDER BY rank DESC, songs.id
+		LIMIT ? OFFSET ?`,
+		searchConfig, headlineOptions, searchConfig, query, limit, offset,
+	).Scan(&rows).Error; err != nil {
+		log.WithError(err).Error("Failed to search songs.")
+		return nil, 0, fmt.Errorf("Failed to search songs: %w", err)
+	}
+
+	results := make([]SongSearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SongSearchResult{Song: row.Song, Rank: row.Rank, Snippet: markHighlights(row.Snippet)})
+	}
+
+	log.WithField("count", len(results)).Info("Full-text search completed")
+	return results, total, nil
+}
+
+// SimilarityKind ограничивает нечёткий поиск группами или песнями.
+type SimilarityKind string
+
+const (
+	SimilarAll    SimilarityKind = ""
+	SimilarGroups SimilarityKind = "group"
+	SimilarSongs  SimilarityKind = "song"
+)
+
+// DefaultSimilarityThreshold совпадает со значением pg_trgm.similarity_threshold по умолчанию.
+const DefaultSimilarityThreshold = 0.3
+
+// SimilarMatch — группа или песня, чьё название похоже на запрос.
+type SimilarMatch struct {
+	Kind       SimilarityKind `json:"type"`
+	ID         uint           `json:"id"`
+	Name       string         `json:"name"`
+	GroupID    uint           `json:"group_id,omitempty"`
+	GroupName  string         `json:"group,omitempty"`
+	Similarity float64        `json:"similarity"`
+}
+
+// SongSuggestion — существующая песня, которую предлагаем вместо ненайденной пары группа/песня.
+type SongSuggestion struct {
+	GroupID    uint    `json:"group_id"`
+	Group      string  `json:"group"`
+	SongID     uint    `json:"song_id"`
+	Song       string  `json:"song"`
+	Similarity float64 `json:"similarity"`
+}
+
+// withSimilarityThreshold выполняет fn в транзакции с заданным pg_trgm.similarity_threshold,
+// чтобы оператор % мог использовать GIN-индексы по триграммам.
+func (repo *SongRepository) withSimilarityThreshold(threshold float64, fn func(tx *gorm.DB) error) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(threshold, 'f', -1, 64)).Error; err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+func (repo *SongRepository) FindSimilar(query string, kind SimilarityKind, threshold float64, limit int) ([]SimilarMatch, error) {
+	log.WithFields(logrus.Fields{"query": query, "type": kind, "threshold": threshold}).Info("Fuzzy searching")
+
+	matches := []SimilarMatch{}
+	err := repo.withSimilarityThreshold(threshold, func(tx *gorm.DB) error {
+		if kind != SimilarSongs {
+			var groups []SimilarMatch
+			if err := tx.Raw(`
+				SELECT 'group' AS kind, id, name, similarity(name, ?) AS similarity
+				FROM groups
+				WHERE name % ? AND deleted_at IS NULL
+				ORDER BY similarity DESC, id
+				LIMIT ?`, query, query, limit).Scan(&groups).Error; err != nil {
+				return err
+			}
+			matches = append(matches, groups...)
+		}
+		if kind != SimilarGroups {
+			var songs []SimilarMatch
+			if err := tx.Raw(`
+				SELECT 'song' AS kind, songs.id, songs.song AS name, groups.id AS group_id, groups.name AS group_name,
+				       similarity(songs.song, ?) AS similarity
+				FROM songs
+				JOIN groups ON groups.id = songs.group_id
+				WHERE songs.song % ? AND songs.deleted_at IS NULL
+				ORDER BY similarity DESC, songs.id
+				LIMIT ?`, query, query, limit).Scan(&songs).Error; err != nil {
+				return err
+			}
+			matches = append(matches, songs...)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to fuzzy search.")
+		return nil, fmt.Errorf("Failed to fuzzy search: %w", err)
+	}
+
+	return sortSimilar(matches, limit), nil
+}
+
+func (repo *SongRepository) SuggestSong(group, song string, threshold float64) (*SongSuggestion, error) {
+	var suggestions []SongSuggestion
+	err := repo.withSimilarityThreshold(threshold, func(tx *gorm.DB) error {
+		return tx.Raw(`
+			SELECT groups.id AS group_id, groups.name AS "group", songs.id AS song_id, songs.song,
+			       (similarity(groups.name, ?) + similarity(songs.song, ?)) / 2 AS similarity
+			FROM songs
+			JOIN groups ON groups.id = songs.group_id
+			WHERE groups.name % ? AND songs.song % ? AND songs.deleted_at IS NULL
+			ORDER BY similarity DESC, songs.id
+			LIMIT 1`, group, song, group, song).Scan(&suggestions).Error
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to suggest song.")
+		return nil, fmt.Errorf("Failed to suggest song: %w", err)
+	}
+	if len(suggestions) == 0 {
+		return nil, nil
+	}
+	return &suggestions[0], nil
+}
+
+// sortSimilar упорядочивает совпадения по убыванию похожести и оставляет не больше limit.
+func sortSimilar(matches []SimilarMatch, limit int) []SimilarMatch {
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Similarity > matches[j].Similarity })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// trigrams разбивает строку на триграммы так же, как pg_trgm:
+// слова в нижнем регистре дополняются двумя пробелами слева и одним справа.
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range tokenize(s) {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
+
+// similarity — аналог функции pg_trgm similarity: доля общих триграмм.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// Suggestion — вариант автодополнения для строки поиска.
+type Suggestion struct {
+	Kind       SimilarityKind `json:"type"`
+	ID         uint           `json:"id"`
+	Text       string         `json:"text"`
+	GroupName  string         `json:"group,omitempty"`
+	Popularity int64          `json:"popularity"`
+	// NamePrefix — название начинается с запроса, а не одно из его слов.
+	NamePrefix bool `json:"-"`
+}
+
+// suggestCandidates — во сколько раз больше групп, чем limit, отбирается по названию
+// перед подсчётом популярности: агрегат по песням считается только для них.
+const suggestCandidates = 4
+
+// Suggest ищет совпадения по началу названия (btree по lower(...)) и по слову внутри
+// названия (триграммный индекс) отдельными запросами, а не одним OR с ведущим '%'.
+func (repo *SongRepository) Suggest(prefix string, kind SimilarityKind, limit int) ([]Suggestion, error) {
+	lowered := escapeLike(strings.ToLower(prefix))
+	namePrefix, wordPrefix := lowered+"%", "% "+lowered+"%"
+
+	suggestions := []Suggestion{}
+	if kind != SimilarSongs {
+		var groups []Suggestion
+		if err := repo.DB.Raw(`
+			WITH candidates AS (
+				(SELECT id, name, true AS name_prefix
+				 FROM groups
+				 WHERE lower(name) LIKE ? AND deleted_at IS NULL
+				 ORDER BY lower(name)
+				 LIMIT ?)
+				UNION ALL
+				(SELECT id, name, false AS name_prefix
+				 FROM groups
+				 WHERE lower(name) LIKE ? AND lower(name) NOT LIKE ? AND deleted_at IS NULL
+				 ORDER BY lower(name)
+				 LIMIT ?)
+			)
+			SELECT 'group' AS kind, candidates.id, candidates.name AS text,
+			       stats.popularity, candidates.name_prefix
+			FROM candidates
+			CROSS JOIN LATERAL (
+				SELECT COALESCE(SUM(songs.lookups), 0) + COUNT(songs.id) AS popularity
+				FROM songs
+				WHERE songs.group_id = candidates.id AND songs.deleted_at IS NULL
+			) stats
+			ORDER BY candidates.name_prefix DESC, stats.popularity DESC, candidates.name
+			LIMIT ?`,
+			namePrefix, limit*suggestCandidates,
+			wordPrefix, namePrefix, limit*suggestCandidates,
+			limit).Scan(&groups).Error; err != nil {
+			log.WithError(err).Error("Failed to suggest groups.")
+			return nil, fmt.Errorf("Failed to suggest groups: %w", err)
+		}
+		suggestions = append(suggestions, groups...)
+	}
+	if kind != SimilarGroups {
+		var songs []Suggestion
+		if err := repo.DB.Raw(`
+			WITH matches AS (
+				(SELECT id, song, group_id, lookups, true AS name_prefix
+				 FROM songs
+				 WHERE lower(song) LIKE ? AND deleted_at IS NULL
+				 ORDER BY lookups DESC, song
+				 LIMIT ?)
+				UNION ALL
+				(SELECT id, song, group_id, lookups, false AS name_prefix
+				 FROM songs
+				 WHERE lower(song) LIKE ? AND lower(song) NOT LIKE ? AND deleted_at IS NULL
+				 ORDER BY lookups DESC, song
+				 LIMIT ?)
+			)
+			SELECT 'song' AS kind, matches.id, matches.song AS text, groups.name AS group_name,
+			       matches.lookups AS popularity, matches.name_prefix
+			FROM matches
+			JOIN groups ON groups.id = matches.group_id
+			ORDER BY matches.name_prefix DESC, popularity DESC, matches.song
+			LIMIT ?`,
+			namePrefix, limit,
+			wordPrefix, namePrefix, limit,
+			limit).Scan(&songs).Error; err != nil {
+			log.WithError(err).Error("Failed to suggest songs.")
+			return nil, fmt.Errorf("Failed to suggest songs: %w", err)
+		}
+		suggestions = append(suggestions, songs...)
+	}
+
+	return sortSuggestions(suggestions, limit), nil
+}
+
+func (repo *SongRepository) RecordLookup(songID uint) error {
+	if err := repo.DB.Model(&models.Song{}).Where("id = ?", songID).
+		UpdateColumn("lookups", gorm.Expr("lookups + 1")).Error; err != nil {
+		log.WithError(err).Error("Failed to record song lookup.")
+		return fmt.Errorf("Failed to record song lookup: %w", err)
+	}
+	return nil
+}
+
+// sortSuggestions ставит совпадения по началу названия выше совпадений по слову,
+// затем упорядочивает по популярности и названию, оставляя не больше limit.
+func sortSuggestions(suggestions []Suggestion, limit int) []Suggestion {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if a.NamePrefix != b.NamePrefix {
+			return a.NamePrefix
+		}
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		return a.Text < b.Text
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
@@ -1,14 +1,20 @@
 package repository
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"music-library/httpclient"
 	"music-library/models"
+	"music-library/utils"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SongRepository struct {
@@ -29,15 +35,52 @@ func NewSongRepository(db *gorm.DB) *SongRepository {
 	return &SongRepository{DB: db}
 }
 
-func (repo *SongRepository) SaveSong(song *models.Song) (*models.Song, error) {
-	if err := repo.DB.Create(song).Error; err != nil {
+func (repo *SongRepository) SaveSong(song *models.Song, author string) (*models.Song, error) {
+	normalizeSong(song)
+	if err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, models.Song{}, song, author, models.RevisionCreate, 0)
+	}); err != nil {
 		log.WithError(err).Errorf("Failed to save song: %v", song)
-		return nil, fmt.Errorf("Failed to save song: %w", err)
+		return nil, fmt.Errorf("Failed to save song: %w", songConflict(err))
 	}
 	log.WithField("song_id", song.ID).Info("Song saved successfully")
 	return song, nil
 }
 
+func (repo *SongRepository) GetOrCreateSong(song *models.Song, author string) (*models.Song, bool, error) {
+	normalizeSong(song)
+	var created bool
+	if err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "group_id"}, {Name: "normalized_song"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL"}}},
+			DoNothing:   true,
+		}).Create(song)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		return recordRevision(tx, models.Song{}, song, author, models.RevisionCreate, 0)
+	}); err != nil {
+		log.WithError(err).Errorf("Failed to save song: %v", song)
+		return nil, false, fmt.Errorf("Failed to save song: %w", err)
+	}
+	if created {
+		log.WithField("song_id", song.ID).Info("Song saved successfully")
+		return song, true, nil
+	}
+
+	// Песню уже вставил параллельный запрос, возвращаем существующую запись
+	existing, err := repo.FindSong(song.GroupID, song.Song)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
 func (repo *SongRepository) GetAllSongs(page, limit int) ([]models.Song, error) {
 	offset := calculateOffset(page, limit)
 	log.WithFields(logrus.Fields{"page": page, "limit": limit, "offset": offset}).Info("Retrieving songs")
@@ -52,6 +95,154 @@ func (repo *SongRepository) GetAllSongs(page, limit int) ([]models.Song, error)
 	return songs, nil
 }
 
+func (repo *SongRepository) FindSongs(filter SongFilter, page, limit int) ([]models.Song, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	offset := calculateOffset(page, limit)
+	log.WithFields(logrus.Fields{"filter": filter, "page": page, "limit": limit}).Info("Searching songs")
+
+	query := applySongFilter(repo.DB.Model(&models.Song{}), filter)
+
+	var songs []models.Song
+	if err := query.Offset(offset).Limit(limit).Find(&songs).Error; err != nil {
+		log.WithError(err).Error("Failed to search songs.")
+		return nil, fmt.Errorf("Failed to search songs: %w", err)
+	}
+
+	log.WithField("count", len(songs)).Info("Songs found successfully")
+	return songs, nil
+}
+
+func (repo *SongRepository) CountSongs(filter SongFilter) (int64, error) {
+	if err := filter.Validate(); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := applySongConditions(repo.DB.Model(&models.Song{}), filter).Count(&total).Error; err != nil {
+		log.WithError(err).Error("Failed to count songs.")
+		return 0, fmt.Errorf("Failed to count songs: %w", err)
+	}
+	return total, nil
+}
+
+var sortColumns = map[SortField]string{
+	SortByID:          "songs.id",
+	SortBySong:        "songs.song",
+	SortByGroup:       "groups.name",
+	SortByReleaseDate: "songs.release_date",
+	SortByCreatedAt:   "songs.created_at",
+}
+
+// applySongFilter добавляет к запросу условия и сортировку из SongFilter.
+// Фильтр должен быть предварительно проверен Validate.
+func applySongFilter(query *gorm.DB, filter SongFilter) *gorm.DB {
+	return applySongOrder(applySongConditions(query, filter), filter, false)
+}
+
+// applySongConditions добавляет к запросу только условия отбора из SongFilter.
+func applySongConditions(query *gorm.DB, filter SongFilter) *gorm.DB {
+	if filter.Group != "" || filter.SortBy == SortByGroup {
+		query = query.Joins("JOIN groups ON groups.id = songs.group_id")
+	}
+
+	match := func(column, value string) {
+		if value == "" {
+			return
+		}
+		if filter.Match == MatchExact {
+			query = query.Where(column+" = ?", value)
+		} else {
+			query = query.Where(column+" ILIKE ?", "%"+escapeLike(value)+"%")
+		}
+	}
+	match("groups.name", filter.Group)
+	match("songs.song", filter.Song)
+	match("songs.link", filter.Link)
+
+	if filter.GroupID != 0 {
+		query = query.Where("songs.group_id = ?", filter.GroupID)
+	}
+	if filter.Text != "" {
+		query = query.Where("songs.text ILIKE ?", "%"+escapeLike(filter.Text)+"%")
+	}
+	if filter.ReleasedFrom != nil {
+		query = query.Where("songs.release_date >= ?", *filter.ReleasedFrom)
+	}
+	if filter.ReleasedTo != nil {
+		query = query.Where("songs.release_date <= ?", *filter.ReleasedTo)
+	}
+	return query
+}
+
+// applySongOrder сортирует по полю фильтра и затем по ID; reverse меняет направление на обратное.
+func applySongOrder(query *gorm.DB, filter SongFilter, reverse bool) *gorm.DB {
+	column := sortColumns[sortFieldOrDefault(filter.SortBy)]
+	direction := "ASC"
+	if filter.SortDesc != reverse {
+		direction = "DESC"
+	}
+	query = query.Order(column + " " + direction)
+	if column != sortColumns[SortByID] {
+		query = query.Order("songs.id " + direction)
+	}
+	return query
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (repo *SongRepository) FindSongsByCursor(filter SongFilter, after string, limit int) (*SongPage, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	position, err := decodeCursor(after, filter)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(logrus.Fields{"filter": filter, "cursor": after, "limit": limit}).Info("Searching songs by cursor")
+
+	query := applySongConditions(repo.DB.Model(&models.Song{}), filter)
+	backward := position != nil && position.Backward
+	if position != nil {
+		query = applyKeyset(query, filter, position)
+	}
+	query = applySongOrder(query, filter, backward)
+	if sortFieldOrDefault(filter.SortBy) == SortByGroup {
+		query = query.Preload("Group")
+	}
+
+	var songs []models.Song
+	if err := query.Limit(limit + 1).Find(&songs).Error; err != nil {
+		log.WithError(err).Error("Failed to search songs.")
+		return nil, fmt.Errorf("Failed to search songs: %w", err)
+	}
+
+	log.WithField("count", len(songs)).Info("Songs found successfully")
+	return buildSongPage(songs, position, filter, limit, func(song models.Song) string {
+		return song.Group.Name
+	}), nil
+}
+
+// applyKeyset отбирает строки строго после позиции курсора в направлении его обхода.
+func applyKeyset(query *gorm.DB, filter SongFilter, position *cursor) *gorm.DB {
+	op := ">"
+	if filter.SortDesc != position.Backward {
+		op = "<"
+	}
+	field := sortFieldOrDefault(filter.SortBy)
+	if field == SortByID {
+		return query.Where("songs.id "+op+" ?", position.ID)
+	}
+	key, _ := parseCursorKey(field, position.Key)
+	return query.Where("("+sortColumns[field]+", songs.id) "+op+" (?, ?)", key, position.ID)
+}
+
 func (repo *SongRepository) GetSongByID(id uint) (*models.Song, error) {
 	log.WithField("song_id", id).Info("Fetching song by ID.")
 
@@ -59,7 +250,7 @@ func (repo *SongRepository) GetSongByID(id uint) (*models.Song, error) {
 	if err := repo.DB.First(&song, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			log.WithField("song_id", id).Warn("Song not found.")
-			return nil, fmt.Errorf("song with ID %d not found", id)
+			return nil, fmt.Errorf("song with ID %d: %w", id, ErrNotFound)
 		}
 		log.WithError(err).Error("Failed to fetch song.")
 		return nil, fmt.Errorf("Failed to fetch song: %w", err)
@@ -69,31 +260,36 @@ func (repo *SongRepository) GetSongByID(id uint) (*models.Song, error) {
 	return &song, nil
 }
 
-func (repo *SongRepository) UpdateSong(song *models.Song) (*models.Song, error) {
-	log.WithField("song_id", song.ID).Info("Updating song")
+func (repo *SongRepository) FindSong(groupID uint, name string) (*models.Song, error) {
+	log.WithFields(logrus.Fields{"group_id": groupID, "song": name}).Info("Fetching song by group and name.")
 
-	if err := repo.DB.First(&models.Song{}, song.ID).Error; err != nil {
+	var song models.Song
+	if err := repo.DB.Where("group_id = ? AND normalized_song = ?", groupID, utils.NameKey(name)).First(&song).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			log.WithField("song_id", song.ID).Warn("Song not found")
-			return nil, fmt.Errorf("song with ID %d not found", song.ID)
+			return nil, fmt.Errorf("song %q of group %d: %w", name, groupID, ErrNotFound)
 		}
-		log.WithError(err).Error("Failed to find song for update")
-		return nil, fmt.Errorf("Failed to find song for update: %w", err)
-	}
-
-	if err := repo.DB.Model(&models.Song{}).Where("id = ?", song.ID).Updates(song).Error; err != nil {
-		log.WithError(err).Errorf("Failed to update song with ID %d", song.ID)
-		return nil, fmt.Errorf("Failed to update song: %w", err)
+		log.WithError(err).Error("Failed to fetch song.")
+		return nil, fmt.Errorf("Failed to fetch song: %w", err)
 	}
 
-	log.WithField("song_id", song.ID).Info("Song updated successfully.")
-	return song, nil
+	return &song, nil
 }
 
-func (repo *SongRepository) DeleteSong(id uint) error {
+func (repo *SongRepository) DeleteSong(id uint, ifVersion int64) error {
 	log.WithField("song_id", id).Info("Deleting song.")
 
-	if err := repo.DB.Delete(&models.Song{}, id).Error; err != nil {
+	if err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		if ifVersion != 0 {
+			song, err := lockSong(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := checkVersion(song, ifVersion); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Song{}, id).Error
+	}); err != nil {
 		log.WithError(err).Errorf("Failed to delete song with ID %d", id)
 		return fmt.Errorf("Failed to delete song: %w", err)
 	}
@@ -102,18 +298,29 @@ func (repo *SongRepository) DeleteSong(id uint) error {
 	return nil
 }
 
-func (repo *SongRepository) PatchSong(id uint, updates map[string]interface{}) (*models.Song, error) {
+func (repo *SongRepository) PatchSong(id uint, patch SongPatch, author string, ifVersion int64) (*models.Song, error) {
 	var song models.Song
-	if err := repo.DB.First(&song, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			log.WithError(err).Error("Song not found")
-			return nil, fmt.Errorf("song with ID %d not found", id)
+	if err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		before, err := lockSong(tx, id)
+		if err != nil {
+			return err
 		}
-		log.WithError(err).Error("Failed to find song")
-		return nil, fmt.Errorf("Failed to find song: %w", err)
-	}
-
-	if err := repo.DB.Model(&song).Updates(updates).Error; err != nil {
+		if err := checkVersion(before, ifVersion); err != nil {
+			return err
+		}
+		song = before
+		columns := patch.columns()
+		if provenance := patch.provenance(before.Provenance, time.Now()); provenance != nil {
+			columns["provenance"] = provenance
+		}
+		if err := tx.Model(&song).Updates(columns).Error; err != nil {
+			return songConflict(err)
+		}
+		if err := tx.First(&song, id).Error; err != nil {
+			return err
+		}
+		return recordRevision(tx, before, &song, author, models.RevisionUpdate, 0)
+	}); err != nil {
 		log.WithError(err).Error("Failde to update song")
 		return nil, fmt.Errorf("Failed to update song: %w", err)
 	}
@@ -122,17 +329,90 @@ func (repo *SongRepository) PatchSong(id uint, updates map[string]interface{}) (
 	return &song, nil
 }
 
-func (repo *SongRepository) FetchSongLyrics(songID uint) (string, error) {
+// GetOrCreateGroup ищет группу по нормализованному названию и создаёт её, если такой нет.
+func (repo *SongRepository) GetOrCreateGroup(name string) (*models.Group, error) {
+	name = utils.CleanName(name)
+	key := utils.NameKey(name)
+
+	var group models.Group
+	err := repo.DB.Where("normalized_name = ?", key).Order("id").First(&group).Error
+	if err == gorm.ErrRecordNotFound {
+		// Группу из корзины восстанавливаем, а не создаём заново: её название занято уникальным индексом
+		err = repo.DB.Unscoped().Where("normalized_name = ? AND deleted_at IS NOT NULL", key).Order("id").First(&group).Error
+		if err == nil {
+			err = repo.DB.Unscoped().Model(&group).Update("deleted_at", nil).Error
+			group.DeletedAt = gorm.DeletedAt{}
+		}
+	}
+	if err == gorm.ErrRecordNotFound {
+		group = models.Group{Name: name, NormalizedName: key}
+		err = repo.DB.Create(&group).Error
+		if isUniqueViolation(err) {
+			// Группу с тем же нормализованным названием только что создал параллельный запрос
+			group = models.Group{}
+			err = repo.DB.Where("normalized_name = ?", key).First(&group).Error
+		}
+	}
+	if err != nil {
+		log.WithError(err).Errorf("Failed to get or create group %q", name)
+		return nil, fmt.Errorf("Failed to get or create group: %w", err)
+	}
+	return &group, nil
+}
+
+func (repo *SongRepository) FindGroupByName(name string) (*models.Group, error) {
+	var group models.Group
+	if err := repo.DB.Where("normalized_name = ?", utils.NameKey(name)).Order("id").First(&group).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group %q: %w", name, ErrNotFound)
+		}
+		log.WithError(err).Error("Failed to fetch group.")
+		return nil, fmt.Errorf("Failed to fetch group: %w", err)
+	}
+	return &group, nil
+}
+
+func (repo *SongRepository) GetGroupByID(id uint) (*models.Group, error) {
+	var group models.Group
+	if err := repo.DB.First(&group, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			log.WithField("group_id", id).Warn("Group not found.")
+			return nil, fmt.Errorf("group with ID %d: %w", id, ErrNotFound)
+		}
+		log.WithError(err).Error("Failed to fetch group.")
+		return nil, fmt.Errorf("Failed to fetch group: %w", err)
+	}
+	return &group, nil
+}
+
+func (repo *SongRepository) GroupNames(ids []uint) (map[uint]string, error) {
+	names := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	var groups []models.Group
+	if err := repo.DB.Unscoped().Select("id", "name").Where("id IN ?", ids).Find(&groups).Error; err != nil {
+		log.WithError(err).Error("Failed to fetch group names.")
+		return nil, fmt.Errorf("Failed to fetch group names: %w", err)
+	}
+	for _, group := range groups {
+		names[group.ID] = group.Name
+	}
+	return names, nil
+}
+
+// FetchSongLyrics получает текст песни из сервиса текстов через общий клиент внешних API,
+// чтобы запрос соблюдал срок ctx, повторы и circuit breaker.
+func (repo *SongRepository) FetchSongLyrics(ctx context.Context, client *httpclient.Client, songID uint) (string, error) {
 	apiURL := fmt.Sprintf("https://api.example.com/lyrics/%d", songID)
-	resp, err := http.Get(apiURL)
+	resp, err := client.Get(ctx, apiURL)
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch")
 		return "", fmt.Errorf("Failed to fetch lyrics: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.WithError(err).Error("Failed to fetch with status: %w", resp.StatusCode)
+		log.WithField("status", resp.StatusCode).Error("Failed to fetch lyrics")
 		return "", fmt.Errorf("Failed to fetch lyrics, status: %d", resp.StatusCode)
 	}
 
@@ -140,7 +420,7 @@ func (repo *SongRepository) FetchSongLyrics(songID uint) (string, error) {
 		Lyrics string `json:"lyrics"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(resp.Body, &response); err != nil {
 		log.WithError(err).Error("Failed to parse lyrics")
 		return "", fmt.Errorf("Failed to parse lyrics: %w", err)
 	}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"time"
+)
+
+// SongStore описывает хранилище песен и групп, через которое работают обработчики.
+// Реализации: SongRepository (gorm/Postgres) и MemoryStore (в памяти).
+type SongStore interface {
+	// SaveSong, GetOrCreateSong и PatchSong сохраняют ревизию песни от имени author.
+	SaveSong(song *models.Song, author string) (*models.Song, error)
+	// GetOrCreateSong вставляет песню, если в группе нет песни с тем же нормализованным названием,
+	// иначе возвращает существующую. Второе значение сообщает, была ли песня создана.
+	GetOrCreateSong(song *models.Song, author string) (*models.Song, bool, error)
+	GetAllSongs(page, limit int) ([]models.Song, error)
+	FindSongs(filter SongFilter, page, limit int) ([]models.Song, error)
+	// FindSongsByCursor возвращает limit песен после курсора after (пустой — с начала).
+	FindSongsByCursor(filter SongFilter, after string, limit int) (*SongPage, error)
+	CountSongs(filter SongFilter) (int64, error)
+	// SearchSongs выполняет полнотекстовый поиск по названию и тексту, возвращая страницу и общее число совпадений.
+	SearchSongs(query string, page, limit int) ([]SongSearchResult, int64, error)
+	// FindSimilar ищет группы и песни с похожими названиями (триграммная похожесть не ниже threshold).
+	FindSimilar(query string, kind SimilarityKind, threshold float64, limit int) ([]SimilarMatch, error)
+	// SuggestSong возвращает самую похожую существующую пару группа/песня или nil.
+	SuggestSong(group, song string, threshold float64) (*SongSuggestion, error)
+	// Suggest возвращает автодополнения по префиксу, упорядоченные по типу совпадения и популярности.
+	Suggest(prefix string, kind SimilarityKind, limit int) ([]Suggestion, error)
+	// RecordLookup увеличивает счётчик обращений к песне, влияющий на популярность в подсказках.
+	RecordLookup(songID uint) error
+	GetSongByID(id uint) (*models.Song, error)
+	FindSong(groupID uint, name string) (*models.Song, error)
+	// PatchSong и DeleteSong при ifVersion != 0 изменяют песню, только если её
+	// версия совпадает с ifVersion, иначе возвращают ErrVersionMismatch.
+	PatchSong(id uint, patch SongPatch, author string, ifVersion int64) (*models.Song, error)
+	DeleteSong(id uint, ifVersion int64) error
+	// ListRevisions возвращает ревизии песни, начиная с последней.
+	ListRevisions(songID uint) ([]models.SongRevision, error)
+	GetRevision(songID uint, revision int) (*models.SongRevision, error)
+	// RestoreRevision возвращает песню к состоянию ревизии, сохраняя откат как новую ревизию.
+	RestoreRevision(songID uint, revision int, author string) (*models.Song, error)
+
+	GetOrCreateGroup(name string) (*models.Group, error)
+	FindGroupByName(name string) (*models.Group, error)
+	GetGroupByID(id uint) (*models.Group, error)
+	// GroupNames возвращает названия групп по ID, включая группы в корзине.
+	GroupNames(ids []uint) (map[uint]string, error)
+	ListGroups(page, limit int) ([]GroupStats, error)
+	CountGroups() (int64, error)
+	// RenameGroup возвращает ErrGroupNameTaken, если название занято другой группой.
+	RenameGroup(id uint, name string) (*models.Group, error)
+	// DeleteGroup удаляет группу; с cascade удаляет и её песни, иначе возвращает ErrGroupNotEmpty.
+	DeleteGroup(id uint, cascade bool) error
+	FindDuplicateGroups() ([]DuplicateGroups, error)
+	MergeGroups(targetID uint, sourceIDs []uint) (*MergeReport, error)
+	MergeDuplicateSongs(groupID uint) (*MergeReport, error)
+	// ListTrash возвращает удалённые песни и группы, начиная с последних, и их общее число.
+	ListTrash(page, limit int) ([]TrashItem, int64, error)
+	// RestoreSong возвращает песню из корзины вместе с её группой.
+	RestoreSong(id uint) (*models.Song, error)
+	// PurgeTrash окончательно удаляет записи, попавшие в корзину раньше before.
+	PurgeTrash(before time.Time) (*PurgeReport, error)
+}
+
+// MatchMode задаёт способ сравнения строковых полей фильтра.
+type MatchMode string
+
+const (
+	MatchContains MatchMode = "contains" // вхождение без учёта регистра
+	MatchExact    MatchMode = "exact"    // точное совпадение
+)
+
+// SortField — поле сортировки списка песен.
+type SortField string
+
+const (
+	SortByID          SortField = "id"
+	SortBySong        SortField = "song"
+	SortByGroup       SortField = "group"
+	SortByReleaseDate SortField = "release_date"
+	SortByCreatedAt   SortField = "created_at"
+)
+
+// SongFilter задаёт фильтры и порядок выборки песен. Пустые поля не учитываются.
+type SongFilter struct {
+	GroupID uint
+	Group   string
+	Song    string
+	Text    string
+	Link    string
+	// Match применяется к Group, Song и Link; Text всегда ищется по вхождению.
+	Match MatchMode
+
+	// Диапазон дат выпуска, обе границы включительно.
+	ReleasedFrom *time.Time
+	ReleasedTo   *time.Time
+
+	SortBy   SortField
+	SortDesc bool
+}
+
+// Validate проверяет значения перечислений и диапазон дат.
+func (f SongFilter) Validate() error {
+	switch f.Match {
+	case "", MatchContains, MatchExact:
+	default:
+		return fmt.Errorf("unknown match mode %q", f.Match)
+	}
+	switch f.SortBy {
+	case "", SortByID, SortBySong, SortByGroup, SortByReleaseDate, SortByCreatedAt:
+	default:
+		return fmt.Errorf("unknown sort field %q", f.SortBy)
+	}
+	if f.ReleasedFrom != nil && f.ReleasedTo != nil && f.ReleasedFrom.After(*f.ReleasedTo) {
+		return fmt.Errorf("released_from must not be after released_to")
+	}
+	return nil
+}
+
+var (
+	_ SongStore = (*SongRepository)(nil)
+	_ SongStore = (*MemoryStore)(nil)
+)
@@ -0,0 +1,270 @@
+package repository
+
+import (
+	"fmt"
+	"music-library/models"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Виды записей в корзине.
+const (
+	TrashSong  = "song"
+	TrashGroup = "group"
+)
+
+// TrashItem — удалённая песня или группа, которую ещё можно восстановить.
+type TrashItem struct {
+	Kind      string    `json:"type"`
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	GroupID   uint      `json:"group_id,omitempty"`
+	GroupName string    `json:"group,omitempty"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// PurgeReport описывает, сколько записей окончательно удалено из корзины.
+type PurgeReport struct {
+	Before time.Time `json:"before"`
+	Songs  int64     `json:"songs_purged"`
+	Groups int64     `json:"groups_purged"`
+}
+
+func (repo *SongRepository) ListTrash(page, limit int) ([]TrashItem, int64, error) {
+	offset := calculateOffset(page, limit)
+	log.WithFields(logrus.Fields{"page": page, "limit": limit}).Info("Retrieving trash")
+
+	var total int64
+	if err := repo.DB.Raw(`
+		SELECT (SELECT COUNT(*) FROM songs WHERE deleted_at IS NOT NULL)
+		     + (SELECT COUNT(*) FROM groups WHERE deleted_at IS NOT NULL)`).
+		Scan(&total).Error; err != nil {
+		log.WithError(err).Error("Failed to count trash.")
+		return nil, 0, fmt.Errorf("Failed to retrieve trash: %w", err)
+	}
+
+	items := []TrashItem{}
+	if err := repo.DB.Raw(`
+		SELECT * FROM (
+			SELECT 'song' AS kind, songs.id, songs.song AS name,
+			       COALESCE(songs.group_id, 0) AS group_id, COALESCE(groups.name, '') AS group_name, songs.deleted_at
+			FROM songs
+			LEFT JOIN groups ON groups.id = songs.group_id
+			WHERE songs.deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'group', id, name, 0, '', deleted_at
+			FROM groups
+			WHERE deleted_at IS NOT NULL
+		) AS trash
+		ORDER BY deleted_at DESC, kind, id
+		LIMIT ? OFFSET ?`, limit, offset).Scan(&items).Error; err != nil {
+		log.WithError(err).Error("Failed to retrieve trash.")
+		return nil, 0, fmt.Errorf("Failed to retrieve trash: %w", err)
+	}
+	return items, total, nil
+}
+
+func (repo *SongRepository) RestoreSong(id uint) (*models.Song, error) {
+	log.WithField("song_id", id).Info("Restoring song")
+
+	var song models.Song
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&song).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("song with ID %d: %w", id, ErrNotInTrash)
+			}
+			return err
+		}
+		// Вместе с песней возвращаем её группу, если та тоже в корзине
+		groupID, err := restoreGroup(tx, song.GroupID)
+		if err == gorm.ErrRecordNotFound {
+			groupID = song.GroupID
+		} else if err != nil {
+			return err
+		}
+		song.GroupID = groupID
+		if err := tx.Unscoped().Model(&song).Updates(map[string]interface{}{
+			"group_id":   groupID,
+			"deleted_at": nil,
+		}).Error; err != nil {
+			return songConflict(err)
+		}
+		song.DeletedAt = gorm.DeletedAt{}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Errorf("Failed to restore song with ID %d", id)
+		return nil, fmt.Errorf("Failed to restore song: %w", err)
+	}
+
+	log.WithField("song_id", id).Info("Song restored successfully.")
+	return &song, nil
+}
+
+// restoreGroup возвращает группу из корзины и её ID. Если её нормализованное название
+// уже занято неудалённой группой, в корзине остаётся она, а возвращается ID занявшей.
+func restoreGroup(tx *gorm.DB, id uint) (uint, error) {
+	var group models.Group
+	if err := tx.Unscoped().First(&group, id).Error; err != nil {
+		return 0, err
+	}
+	if !group.DeletedAt.Valid {
+		return group.ID, nil
+	}
+	var live models.Group
+	err := tx.Where("normalized_name = ?", group.NormalizedName).First(&live).Error
+	if err == nil {
+		return live.ID, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return 0, err
+	}
+	if err := tx.Unscoped().Model(&group).Update("deleted_at", nil).Error; err != nil {
+		return 0, err
+	}
+	return group.ID, nil
+}
+
+func (repo *SongRepository) PurgeTrash(before time.Time) (*PurgeReport, error) {
+	log.WithField("before", before).Info("Purging trash")
+
+	report := &PurgeReport{Before: before}
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		songs := tx.Unscoped().Where("deleted_at < ?", before).Delete(&models.Song{})
+		if songs.Error != nil {
+			return songs.Error
+		}
+		// Группу нельзя удалить, пока на неё ссылаются песни, в том числе ещё не устаревшие в корзине
+		groups := tx.Unscoped().
+			Where("deleted_at < ? AND NOT EXISTS (SELECT 1 FROM songs WHERE songs.group_id = groups.id)", before).
+			Delete(&models.Group{})
+		if groups.Error != nil {
+			return groups.Error
+		}
+		report.Songs, report.Groups = songs.RowsAffected, groups.RowsAffected
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to purge trash.")
+		return nil, fmt.Errorf("Failed to purge trash: %w", err)
+	}
+
+	log.WithFields(logrus.Fields{"songs": report.Songs, "groups": report.Groups}).Info("Trash purged successfully.")
+	return report, nil
+}
+
+func (m *MemoryStore) ListTrash(page, limit int) ([]TrashItem, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	items := make([]TrashItem, 0, len(m.trashedSongs)+len(m.trashedGroups))
+	for _, song := range m.trashedSongs {
+		item := TrashItem{Kind: TrashSong, ID: song.ID, Name: song.Song, GroupID: song.GroupID, DeletedAt: song.DeletedAt.Time}
+		if group, ok := m.anyGroup(song.GroupID); ok {
+			item.GroupName = group.Name
+		}
+		items = append(items, item)
+	}
+	for _, group := range m.trashedGroups {
+		items = append(items, TrashItem{Kind: TrashGroup, ID: group.ID, Name: group.Name, DeletedAt: group.DeletedAt.Time})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if !a.DeletedAt.Equal(b.DeletedAt) {
+			return a.DeletedAt.After(b.DeletedAt)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+
+	return paginate(items, page, limit), int64(len(items)), nil
+}
+
+func (m *MemoryStore) RestoreSong(id uint) (*models.Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	song, ok := m.trashedSongs[id]
+	if !ok {
+		return nil, fmt.Errorf("Failed to restore song: song with ID %d: %w", id, ErrNotInTrash)
+	}
+	if m.songExists(song.GroupID, song.NormalizedSong, song.ID) {
+		return nil, fmt.Errorf("Failed to restore song: %w", ErrSongExists)
+	}
+	if group, ok := m.trashedGroups[song.GroupID]; ok {
+		group.DeletedAt = gorm.DeletedAt{}
+		m.groups[group.ID] = group
+		delete(m.trashedGroups, group.ID)
+	}
+
+	song.DeletedAt = gorm.DeletedAt{}
+	song.UpdatedAt = time.Now()
+	m.songs[id] = song
+	delete(m.trashedSongs, id)
+	return &song, nil
+}
+
+func (m *MemoryStore) PurgeTrash(before time.Time) (*PurgeReport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := &PurgeReport{Before: before}
+	for id, song := range m.trashedSongs {
+		if song.DeletedAt.Time.Before(before) {
+			delete(m.trashedSongs, id)
+			delete(m.revisions, id)
+			report.Songs++
+		}
+	}
+
+	referenced := make(map[uint]bool)
+	for _, song := range m.songs {
+		referenced[song.GroupID] = true
+	}
+	for _, song := range m.trashedSongs {
+		referenced[song.GroupID] = true
+	}
+	for id, group := range m.trashedGroups {
+		if group.DeletedAt.Time.Before(before) && !referenced[id] {
+			delete(m.trashedGroups, id)
+			report.Groups++
+		}
+	}
+	return report, nil
+}
+
+// trashSong переносит песню в корзину. Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) trashSong(id uint, now time.Time) {
+	song, ok := m.songs[id]
+	if !ok {
+		return
+	}
+	song.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	m.trashedSongs[id] = song
+	delete(m.songs, id)
+}
+
+// trashGroup переносит группу в корзину. Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) trashGroup(id uint, now time.Time) {
+	group, ok := m.groups[id]
+	if !ok {
+		return
+	}
+	group.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+	m.trashedGroups[id] = group
+	delete(m.groups, id)
+}
+
+// anyGroup ищет группу и среди живых, и в корзине. Вызывающий должен удерживать m.mu.
+func (m *MemoryStore) anyGroup(id uint) (models.Group, bool) {
+	if group, ok := m.groups[id]; ok {
+		return group, true
+	}
+	group, ok := m.trashedGroups[id]
+	return group, ok
+}
@@ -0,0 +1,124 @@
+package utils
+
+import "strings"
+
+// Операции построчного сравнения.
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine — строка результата сравнения текстов.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines сравнивает тексты построчно по наибольшей общей подпоследовательности:
+// строки, которых нет в to, помечаются delete, отсутствующие в from — insert.
+// Подпоследовательность ищется алгоритмом Хиршберга, поэтому память растёт
+// линейно от длины текстов, а не от их произведения.
+func DiffLines(from, to string) []DiffLine {
+	a, b := splitLines(from), splitLines(to)
+	lines := make([]DiffLine, 0, len(a)+len(b))
+
+	// общие начало и конец не участвуют в поиске
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+	lines = diffRange(lines, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+	}
+	return lines
+}
+
+// diffRange делит a пополам и ищет в b точку разреза, на которой сумма
+// подпоследовательностей левой и правой половин максимальна.
+func diffRange(lines []DiffLine, a, b []string) []DiffLine {
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			lines = append(lines, DiffLine{Op: DiffInsert, Text: line})
+		}
+		return lines
+	case len(b) == 0:
+		for _, line := range a {
+			lines = append(lines, DiffLine{Op: DiffDelete, Text: line})
+		}
+		return lines
+	case len(a) == 1:
+		for j, line := range b {
+			if line == a[0] {
+				lines = diffRange(lines, nil, b[:j])
+				lines = append(lines, DiffLine{Op: DiffEqual, Text: line})
+				return diffRange(lines, nil, b[j+1:])
+			}
+		}
+		lines = append(lines, DiffLine{Op: DiffDelete, Text: a[0]})
+		return diffRange(lines, nil, b)
+	}
+
+	mid := len(a) / 2
+	head := lcsPrefix(a[:mid], b)
+	tail := lcsSuffix(a[mid:], b)
+
+	cut, best := 0, -1
+	for j := range head {
+		if head[j]+tail[j] > best {
+			cut, best = j, head[j]+tail[j]
+		}
+	}
+
+	lines = diffRange(lines, a[:mid], b[:cut])
+	return diffRange(lines, a[mid:], b[cut:])
+}
+
+// lcsPrefix возвращает длины общей подпоследовательности a и b[:j] для каждого j.
+func lcsPrefix(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// lcsSuffix возвращает длины общей подпоследовательности a и b[j:] для каждого j.
+func lcsSuffix(a, b []string) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     []DiffLine
+	}{
+		{name: "both empty", want: []DiffLine{}},
+		{
+			name: "equal",
+			from: "a\nb",
+			to:   "a\nb",
+			want: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+		{
+			name: "from empty",
+			to:   "a\nb",
+			want: []DiffLine{{DiffInsert, "a"}, {DiffInsert, "b"}},
+		},
+		{
+			name: "to empty",
+			from: "a\nb",
+			want: []DiffLine{{DiffDelete, "a"}, {DiffDelete, "b"}},
+		},
+		{
+			name: "changed line",
+			from: "a\nb\nc",
+			to:   "a\nx\nc",
+			want: []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "x"}, {DiffEqual, "c"}},
+		},
+		{
+			name: "inserted and deleted lines",
+			from: "a\nb\nc\nd",
+			to:   "b\nc\ne\nd",
+			want: []DiffLine{{DiffDelete, "a"}, {DiffEqual, "b"}, {DiffEqual, "c"}, {DiffInsert, "e"}, {DiffEqual, "d"}},
+		},
+		{
+			name: "windows line endings",
+			from: "a\r\nb",
+			to:   "a\nb",
+			want: []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lcsLength — длина наибольшей общей подпоследовательности по полной таблице, для проверки DiffLines.
+func lcsLength(a, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func TestDiffLinesIsMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	text := func() string {
+		lines := make([]string, random.Intn(15))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return strings.Join(lines, "\n")
+	}
+	for i := 0; i < 2000; i++ {
+		from, to := text(), text()
+		var equal int
+		var before, after []string
+		for _, line := range DiffLines(from, to) {
+			switch line.Op {
+			case DiffEqual:
+				equal++
+				before, after = append(before, line.Text), append(after, line.Text)
+			case DiffDelete:
+				before = append(before, line.Text)
+			case DiffInsert:
+				after = append(after, line.Text)
+			}
+		}
+		if strings.Join(before, "\n") != from || strings.Join(after, "\n") != to {
+			t.Fatalf("DiffLines(%q, %q) does not reproduce the texts", from, to)
+		}
+		if want := lcsLength(splitLines(from), splitLines(to)); equal != want {
+			t.Fatalf("DiffLines(%q, %q) keeps %d lines, want %d", from, to, equal, want)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// CleanName приводит название группы или песни к виду для хранения:
+// Unicode NFC, без пробелов по краям и с одиночными пробелами между словами.
+func CleanName(s string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(s)), " ")
+}
+
+// NameKey возвращает ключ для поиска дубликатов: "Muse", "muse " и "MUSE" дают один ключ,
+// как и "The Beatles", "Beatles" и "Beatles, The".
+// Должен совпадать с SQL-функцией music_name_key из миграции 000007.
+func NameKey(s string) string {
+	key := strings.Join(strings.Fields(strings.ToLower(norm.NFKC.String(s))), " ")
+	key = strings.TrimSuffix(key, ", the")
+	if rest := strings.TrimPrefix(key, "the "); rest != "" {
+		key = rest
+	}
+	return key
+}
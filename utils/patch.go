@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch — патч составлен неверно: неизвестная операция, плохой путь, нет value.
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchConflict — патч нельзя применить к текущему документу: пути нет или не прошёл test.
+	ErrPatchConflict = errors.New("patch cannot be applied")
+)
+
+// PatchOperation — операция JSON Patch (RFC 6902).
+type PatchOperation struct {
+	Op   string `json:"op" enums:"add,remove,replace,move,copy,test"`
+	Path string `json:"path" example:"/text"`
+	From string `json:"from,omitempty"`
+	// Value хранится как есть, чтобы отличать "value": null от отсутствующего поля.
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// MergePatch применяет JSON Merge Patch (RFC 7396) к документу, разобранному encoding/json.
+// Исходный документ не изменяется.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	result := map[string]interface{}{}
+	if targetObject, ok := target.(map[string]interface{}); ok {
+		for key, value := range targetObject {
+			result[key] = value
+		}
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = MergePatch(result[key], value)
+	}
+	return result
+}
+
+// ApplyJSONPatch применяет операции JSON Patch (RFC 6902) к документу, разобранному encoding/json.
+// Патч применяется целиком или не применяется вовсе: исходный документ не изменяется.
+func ApplyJSONPatch(doc interface{}, operations []PatchOperation) (interface{}, error) {
+	doc = deepCopy(doc)
+	for i, op := range operations {
+		var err error
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return setPointer(doc, path, value, true)
+		case "replace":
+			return setPointer(doc, path, value, false)
+		}
+		current, err := getPointer(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: test failed", ErrPatchConflict)
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := removePointer(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+			}
+			if doc, value, err = removePointer(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = getPointer(doc, from); err != nil {
+				return nil, err
+			}
+			value = deepCopy(value)
+		}
+		return setPointer(doc, path, value, true)
+	}
+	return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901) на ссылки.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func getPointer(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+		}
+	}
+	return doc, nil
+}
+
+// setPointer записывает value по пути. insert = true — операция add (в массив вставляет элемент),
+// иначе replace (значение по пути должно существовать).
+func setPointer(doc interface{}, path []string, value interface{}, insert bool) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if last {
+			if _, ok := node[token]; !ok && !insert {
+				return nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+			}
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+		}
+		child, err := setPointer(child, path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		if last && insert {
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			node[i] = value
+			return node, nil
+		}
+		if node[i], err = setPointer(node[i], path[1:], value, insert); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+}
+
+// removePointer удаляет значение по пути и возвращает изменённый документ и удалённое значение.
+func removePointer(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+		}
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := removePointer(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := removePointer(node[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[i] = child
+		return node, removed, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %q not found", ErrPatchConflict, token)
+}
+
+// arrayIndex разбирает индекс массива из JSON Pointer; допустимы значения от 0 до max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	if i > max {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrPatchConflict, i)
+	}
+	return i, nil
+}
+
+func deepCopy(doc interface{}) interface{} {
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			result[key] = deepCopy(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(node))
+		for i, value := range node {
+			result[i] = deepCopy(value)
+		}
+		return result
+	}
+	return doc
+}
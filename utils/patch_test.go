@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "add object member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"foo":"bar","baz":"qux"}`,
+		},
+		{
+			name:  "add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "add to the end of an array",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":"baz"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "add null value",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":null}]`,
+			want:  `{"foo":"bar","baz":null}`,
+		},
+		{
+			name:  "replace the whole document",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"","value":{"baz":1}}]`,
+			want:  `{"baz":1}`,
+		},
+		{
+			name:  "remove object member",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "replace value",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "move value",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "move array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "copy value is independent of the source",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:  "escaped pointer tokens",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:    "test fails",
+			doc:     `{"baz":"qux"}`,
+			patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantErr: ErrPatchConflict,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"replace","path":"/baz","value":1}]`,
+			wantErr: ErrPatchConflict,
+		},
+		{
+			name:    "add to missing parent",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantErr: ErrPatchConflict,
+		},
+		{
+			name:    "array index out of range",
+			doc:     `{"foo":["bar"]}`,
+			patch:   `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			wantErr: ErrPatchConflict,
+		},
+		{
+			name:    "array index with leading zero",
+			doc:     `{"foo":["bar","baz"]}`,
+			patch:   `[{"op":"remove","path":"/foo/01"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "path without leading slash",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"remove","path":"foo"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "missing value",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "unknown operation",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"merge","path":"/foo","value":1}]`,
+			wantErr: ErrInvalidPatch,
+		},
+		{
+			name:    "move into its own child",
+			doc:     `{"a":{"b":{}}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			wantErr: ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var operations []PatchOperation
+			if err := json.Unmarshal([]byte(tt.patch), &operations); err != nil {
+				t.Fatalf("invalid patch: %v", err)
+			}
+			doc := decodeJSON(t, tt.doc)
+			got, err := ApplyJSONPatch(doc, operations)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("result = %v, want %v", got, want)
+			}
+			if original := decodeJSON(t, tt.doc); !reflect.DeepEqual(doc, original) {
+				t.Errorf("source document changed to %v", doc)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchIsAtomic(t *testing.T) {
+	doc := decodeJSON(t, `{"foo":"bar"}`)
+	operations := []PatchOperation{
+		{Op: "replace", Path: "/foo", Value: json.RawMessage(`"baz"`)},
+		{Op: "remove", Path: "/missing"},
+	}
+	if _, err := ApplyJSONPatch(doc, operations); !errors.Is(err, ErrPatchConflict) {
+		t.Fatalf("error = %v, want %v", err, ErrPatchConflict)
+	}
+	if want := decodeJSON(t, `{"foo":"bar"}`); !reflect.DeepEqual(doc, want) {
+		t.Errorf("document = %v, want %v", doc, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" + "+tt.patch, func(t *testing.T) {
+			got := MergePatch(decodeJSON(t, tt.target), decodeJSON(t, tt.patch))
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("MergePatch = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -1,6 +1,29 @@
 package utils
 
-import "strconv"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения полей песни. Длины названий и ссылки совпадают с колонками VARCHAR
+// из миграции 000002 и считаются в символах, размер текста — в байтах.
+const (
+	MaxNameLength = 255
+	MaxLinkLength = 1000
+	MaxTextBytes  = 64 << 10
+)
+
+// dateLayouts — форматы даты выпуска, которые принимаются от клиентов и внешнего API.
+// DateFormats перечисляет их для сообщений об ошибках и документации.
+var dateLayouts = []string{"2006-01-02", "02.01.2006", time.RFC3339}
+
+const DateFormats = "YYYY-MM-DD, DD.MM.YYYY or RFC 3339"
 
 func ToInt(s string) int {
 	val, err := strconv.Atoi(s)
@@ -10,3 +33,71 @@ func ToInt(s string) int {
 
 	return val
 }
+
+// ParseDate разбирает дату выпуска в формате YYYY-MM-DD, DD.MM.YYYY или RFC 3339.
+// Пробелы по краям отбрасываются.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errors.New("must be a date in " + DateFormats + " format")
+}
+
+// ValidateName проверяет название группы или песни, уже приведённое CleanName.
+func ValidateName(s string) error {
+	if s == "" {
+		return errors.New("must not be empty")
+	}
+	if err := validateString(s, false); err != nil {
+		return err
+	}
+	if n := utf8.RuneCountInString(s); n > MaxNameLength {
+		return fmt.Errorf("must be at most %d characters long, got %d", MaxNameLength, n)
+	}
+	return nil
+}
+
+// ValidateLink проверяет ссылку на песню: пустая строка или абсолютный http(s) URL.
+func ValidateLink(s string) error {
+	if s == "" {
+		return nil
+	}
+	if err := validateString(s, false); err != nil {
+		return err
+	}
+	if n := utf8.RuneCountInString(s); n > MaxLinkLength {
+		return fmt.Errorf("must be at most %d characters long, got %d", MaxLinkLength, n)
+	}
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(s, " \t") {
+		return errors.New("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// ValidateText проверяет текст песни. Переводы строк и табуляция разрешены.
+func ValidateText(s string) error {
+	if len(s) > MaxTextBytes {
+		return fmt.Errorf("must be at most %d bytes long, got %d", MaxTextBytes, len(s))
+	}
+	return validateString(s, true)
+}
+
+// validateString отклоняет невалидный UTF-8 и управляющие символы; multiline разрешает \n, \r и \t.
+func validateString(s string, multiline bool) error {
+	if !utf8.ValidString(s) {
+		return errors.New("must be valid UTF-8")
+	}
+	for _, r := range s {
+		if multiline && (r == '\n' || r == '\r' || r == '\t') {
+			continue
+		}
+		if unicode.IsControl(r) {
+			return fmt.Errorf("must not contain control characters (found %U)", r)
+		}
+	}
+	return nil
+}